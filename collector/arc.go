@@ -0,0 +1,330 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultARCProps = `c,c_max,c_min,deleted,hits,l2_hits,l2_misses,l2_size,mfu_size,misses,mru_size,size`
+)
+
+var (
+	arcProperties = propertyStore{
+		defaultSubsystem: subsystemARC,
+		store: map[string]property{
+			`anon_size`: newProperty(
+				subsystemARC,
+				`anon_size_bytes`,
+				`Size in bytes of anonymous buffers in the ARC, which are not yet written to disk.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`c`: newProperty(
+				subsystemARC,
+				`target_size_bytes`,
+				`Target size in bytes of the ARC.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`c_max`: newProperty(
+				subsystemARC,
+				`max_size_bytes`,
+				`Maximum target size in bytes of the ARC.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`c_min`: newProperty(
+				subsystemARC,
+				`min_size_bytes`,
+				`Minimum target size in bytes of the ARC.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`data_size`: newProperty(
+				subsystemARC,
+				`data_size_bytes`,
+				`Size in bytes of data buffers in the ARC.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`deleted`: newProperty(
+				subsystemARC,
+				`deleted_total`,
+				`Number of buffers evicted from the ARC.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`demand_data_hits`: newProperty(
+				subsystemARC,
+				`demand_data_hits_total`,
+				`Number of ARC hits for demand reads of data.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`demand_data_misses`: newProperty(
+				subsystemARC,
+				`demand_data_misses_total`,
+				`Number of ARC misses for demand reads of data.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`demand_metadata_hits`: newProperty(
+				subsystemARC,
+				`demand_metadata_hits_total`,
+				`Number of ARC hits for demand reads of metadata.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`demand_metadata_misses`: newProperty(
+				subsystemARC,
+				`demand_metadata_misses_total`,
+				`Number of ARC misses for demand reads of metadata.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`evict_l2_cached`: newProperty(
+				subsystemARC,
+				`evict_l2_cached_bytes_total`,
+				`Amount of data in bytes evicted from the ARC that was cached in the L2ARC.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`evict_l2_eligible`: newProperty(
+				subsystemARC,
+				`evict_l2_eligible_bytes_total`,
+				`Amount of data in bytes evicted from the ARC that was eligible for the L2ARC.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`evict_l2_ineligible`: newProperty(
+				subsystemARC,
+				`evict_l2_ineligible_bytes_total`,
+				`Amount of data in bytes evicted from the ARC that was not eligible for the L2ARC.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`evict_skip`: newProperty(
+				subsystemARC,
+				`evict_skip_total`,
+				`Number of buffers skipped during ARC eviction.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`hdr_size`: newProperty(
+				subsystemARC,
+				`header_size_bytes`,
+				`Size in bytes of ARC headers.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`hits`: newProperty(
+				subsystemARC,
+				`hits_total`,
+				`Number of ARC hits.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`l2_asize`: newProperty(
+				subsystemARC,
+				`l2_allocated_bytes`,
+				`Amount of space in bytes allocated on L2ARC devices, after compression.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`l2_hdr_size`: newProperty(
+				subsystemARC,
+				`l2_header_size_bytes`,
+				`Size in bytes of ARC headers for buffers cached in the L2ARC.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`l2_hits`: newProperty(
+				subsystemARC,
+				`l2_hits_total`,
+				`Number of L2ARC hits.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`l2_misses`: newProperty(
+				subsystemARC,
+				`l2_misses_total`,
+				`Number of L2ARC misses.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`l2_read_bytes`: newProperty(
+				subsystemARC,
+				`l2_read_bytes_total`,
+				`Amount of data in bytes read from L2ARC devices.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`l2_size`: newProperty(
+				subsystemARC,
+				`l2_size_bytes`,
+				`Size in bytes of data cached in the L2ARC, before compression.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`l2_write_bytes`: newProperty(
+				subsystemARC,
+				`l2_write_bytes_total`,
+				`Amount of data in bytes written to L2ARC devices.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`memory_throttle_count`: newProperty(
+				subsystemARC,
+				`memory_throttle_total`,
+				`Number of times ARC writes were throttled due to memory pressure.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`metadata_size`: newProperty(
+				subsystemARC,
+				`metadata_size_bytes`,
+				`Size in bytes of metadata buffers in the ARC.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`mfu_ghost_hits`: newProperty(
+				subsystemARC,
+				`mfu_ghost_hits_total`,
+				`Number of hits on the MFU ghost list, where a buffer had recently been evicted from the MFU list.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`mfu_hits`: newProperty(
+				subsystemARC,
+				`mfu_hits_total`,
+				`Number of hits on the most frequently used (MFU) list.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`mfu_size`: newProperty(
+				subsystemARC,
+				`mfu_size_bytes`,
+				`Size in bytes of the most frequently used (MFU) list.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`misses`: newProperty(
+				subsystemARC,
+				`misses_total`,
+				`Number of ARC misses.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`mru_ghost_hits`: newProperty(
+				subsystemARC,
+				`mru_ghost_hits_total`,
+				`Number of hits on the MRU ghost list, where a buffer had recently been evicted from the MRU list.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`mru_hits`: newProperty(
+				subsystemARC,
+				`mru_hits_total`,
+				`Number of hits on the most recently used (MRU) list.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`mru_size`: newProperty(
+				subsystemARC,
+				`mru_size_bytes`,
+				`Size in bytes of the most recently used (MRU) list.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+			`prefetch_data_hits`: newProperty(
+				subsystemARC,
+				`prefetch_data_hits_total`,
+				`Number of ARC hits for prefetch reads of data.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`prefetch_data_misses`: newProperty(
+				subsystemARC,
+				`prefetch_data_misses_total`,
+				`Number of ARC misses for prefetch reads of data.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`prefetch_metadata_hits`: newProperty(
+				subsystemARC,
+				`prefetch_metadata_hits_total`,
+				`Number of ARC hits for prefetch reads of metadata.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`prefetch_metadata_misses`: newProperty(
+				subsystemARC,
+				`prefetch_metadata_misses_total`,
+				`Number of ARC misses for prefetch reads of metadata.`,
+				transformNumeric,
+				prometheus.CounterValue,
+			),
+			`size`: newProperty(
+				subsystemARC,
+				`size_bytes`,
+				`Current size in bytes of the ARC.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+			),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`arc`, defaultDisabled, defaultARCProps, newARCCollector)
+}
+
+type arcCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *arcCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		prop, err := arcProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `arc`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *arcCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	stats, err := c.client.ARCStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range c.props {
+		v, ok := stats[k]
+		if !ok {
+			// Available statistics vary between ZFS versions.
+			c.log.Debug(`Statistic unavailable`, `collector`, `arc`, `property`, k)
+			continue
+		}
+		prop, err := arcProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `arc`, `property`, k, `err`, err)
+		}
+		if err = prop.push(ch, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newARCCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return &arcCollector{log: l, client: c, props: expandProperties(props, arcProperties.names(), false)}, nil
+}
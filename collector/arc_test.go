@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestARCMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		metricNames    []string
+		statsResults   map[string]string
+		metricResults  string
+	}{
+		{
+			name:           `default metrics`,
+			propsRequested: strings.Split(defaultARCProps, `,`),
+			metricNames:    []string{`zfs_arc_target_size_bytes`, `zfs_arc_max_size_bytes`, `zfs_arc_min_size_bytes`, `zfs_arc_deleted_total`, `zfs_arc_hits_total`, `zfs_arc_l2_hits_total`, `zfs_arc_l2_misses_total`, `zfs_arc_l2_size_bytes`, `zfs_arc_mfu_size_bytes`, `zfs_arc_misses_total`, `zfs_arc_mru_size_bytes`, `zfs_arc_size_bytes`},
+			statsResults: map[string]string{
+				`hits`:      `142213587`,
+				`misses`:    `1234567`,
+				`c`:         `4294967296`,
+				`c_min`:     `1073741824`,
+				`c_max`:     `8589934592`,
+				`size`:      `4000000000`,
+				`mru_size`:  `1500000000`,
+				`mfu_size`:  `2000000000`,
+				`deleted`:   `54321`,
+				`l2_hits`:   `1000`,
+				`l2_misses`: `2000`,
+				`l2_size`:   `0`,
+				`p`:         `1073741824`,
+			},
+			metricResults: `# HELP zfs_arc_deleted_total Number of buffers evicted from the ARC.
+# TYPE zfs_arc_deleted_total counter
+zfs_arc_deleted_total 54321
+# HELP zfs_arc_hits_total Number of ARC hits.
+# TYPE zfs_arc_hits_total counter
+zfs_arc_hits_total 1.42213587e+08
+# HELP zfs_arc_l2_hits_total Number of L2ARC hits.
+# TYPE zfs_arc_l2_hits_total counter
+zfs_arc_l2_hits_total 1000
+# HELP zfs_arc_l2_misses_total Number of L2ARC misses.
+# TYPE zfs_arc_l2_misses_total counter
+zfs_arc_l2_misses_total 2000
+# HELP zfs_arc_l2_size_bytes Size in bytes of data cached in the L2ARC, before compression.
+# TYPE zfs_arc_l2_size_bytes gauge
+zfs_arc_l2_size_bytes 0
+# HELP zfs_arc_max_size_bytes Maximum target size in bytes of the ARC.
+# TYPE zfs_arc_max_size_bytes gauge
+zfs_arc_max_size_bytes 8.589934592e+09
+# HELP zfs_arc_mfu_size_bytes Size in bytes of the most frequently used (MFU) list.
+# TYPE zfs_arc_mfu_size_bytes gauge
+zfs_arc_mfu_size_bytes 2e+09
+# HELP zfs_arc_min_size_bytes Minimum target size in bytes of the ARC.
+# TYPE zfs_arc_min_size_bytes gauge
+zfs_arc_min_size_bytes 1.073741824e+09
+# HELP zfs_arc_misses_total Number of ARC misses.
+# TYPE zfs_arc_misses_total counter
+zfs_arc_misses_total 1.234567e+06
+# HELP zfs_arc_mru_size_bytes Size in bytes of the most recently used (MRU) list.
+# TYPE zfs_arc_mru_size_bytes gauge
+zfs_arc_mru_size_bytes 1.5e+09
+# HELP zfs_arc_size_bytes Current size in bytes of the ARC.
+# TYPE zfs_arc_size_bytes gauge
+zfs_arc_size_bytes 4e+09
+# HELP zfs_arc_target_size_bytes Target size in bytes of the ARC.
+# TYPE zfs_arc_target_size_bytes gauge
+zfs_arc_target_size_bytes 4.294967296e+09
+`,
+		},
+		{
+			name:           `unavailable statistic`,
+			propsRequested: []string{`hits`, `l2_asize`},
+			metricNames:    []string{`zfs_arc_hits_total`, `zfs_arc_l2_allocated_bytes`},
+			statsResults: map[string]string{
+				`hits`: `1024`,
+			},
+			metricResults: `# HELP zfs_arc_hits_total Number of ARC hits.
+# TYPE zfs_arc_hits_total counter
+zfs_arc_hits_total 1024
+`,
+		},
+		{
+			name:           `unsupported metric`,
+			propsRequested: []string{`unsupported`},
+			metricNames:    []string{`zfs_arc_unsupported`},
+			statsResults: map[string]string{
+				`unsupported`: `1024`,
+			},
+			metricResults: `# HELP zfs_arc_unsupported !!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/pdf/zfs_exporter/issues to have this property supported !!!
+# TYPE zfs_arc_unsupported gauge
+zfs_arc_unsupported 1024
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsClient.EXPECT().ARCStats(gomock.Any()).Return(tc.statsResults, nil).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`arc`: {
+					Name:       "arc",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newARCCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
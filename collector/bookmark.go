@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultBookmarkProps = `creation`
+)
+
+var (
+	bookmarkLabels = []string{`name`, `pool`}
+
+	bookmarkMetrics = newSummaryMetrics(
+		`dataset-bookmark`,
+		subsystemBookmark,
+		`count`,
+		`Number of bookmarks of the dataset.`,
+		bookmarkLabels,
+		map[string][]summaryProperty{
+			`creation`: {
+				newSummaryProperty(
+					subsystemBookmark,
+					`oldest_timestamp_seconds`,
+					`The unix timestamp when the oldest bookmark of the dataset was created.`,
+					bookmarkLabels,
+					func(s *summary) float64 { return s.oldest },
+				),
+				newSummaryProperty(
+					subsystemBookmark,
+					`newest_timestamp_seconds`,
+					`The unix timestamp when the newest bookmark of the dataset was created.`,
+					bookmarkLabels,
+					func(s *summary) float64 { return s.newest },
+				),
+			},
+		},
+	)
+)
+
+func init() {
+	registerCollector(`dataset-bookmark`, defaultDisabled, defaultBookmarkProps, newBookmarkCollector)
+	registerCollectorOption(
+		`dataset-bookmark`,
+		datasetIncludeOption,
+		`Only include bookmarks that match the provided regex for the dataset-bookmark collector (e.g. '^tank/backup/'). When anchored with '^', only the datasets below the longest dataset name in the literal prefix are queried, which must exist.`,
+		``,
+	)
+	registerCollectorOption(
+		`dataset-bookmark`,
+		datasetExcludeOption,
+		`Exclude bookmarks that match the provided regex for the dataset-bookmark collector, in addition to --exclude.`,
+		``,
+	)
+	registerCollectorOption(
+		`dataset-bookmark`,
+		datasetDepthOption,
+		`Only include bookmarks of datasets at most this many levels below the pool root for the dataset-bookmark collector (default: unlimited).`,
+		``,
+	)
+}
+
+type bookmarkCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+	filter datasetFilter
+}
+
+func (c *bookmarkCollector) describe(ch chan<- *prometheus.Desc) {
+	bookmarkMetrics.describe(c.props, ch)
+}
+
+func (c *bookmarkCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool, roots.pool(pool), excludes); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *bookmarkCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, roots []string, excludes regexpCollection) error {
+	bookmarks, err := c.filter.datasets(ctx, c.client, pool, zfs.DatasetBookmark, roots, excludes, summaryRequestProps(c.props)...)
+	if err != nil {
+		return err
+	}
+
+	summaries := make(datasetSummaries)
+	for _, bookmark := range bookmarks {
+		name := bookmark.DatasetName()
+		dataset, _, ok := strings.Cut(name, `#`)
+		if !ok {
+			return fmt.Errorf("invalid bookmark name: %s", name)
+		}
+		if err = summaries.add(dataset, []string{dataset, pool}, bookmark.Properties()); err != nil {
+			return err
+		}
+	}
+	bookmarkMetrics.push(c.props, ch, summaries)
+
+	return nil
+}
+
+func newBookmarkCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	filter, err := newDatasetFilter(options)
+	if err != nil {
+		return nil, err
+	}
+	return &bookmarkCollector{log: l, client: c, props: bookmarkMetrics.supported(l, props), filter: filter}, nil
+}
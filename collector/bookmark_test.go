@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBookmarkMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		options        map[string]*string
+		excludes       []string
+		root           string
+		depth          int
+		metricNames    []string
+		propsResults   []datasetResults
+		metricResults  string
+	}{
+		{
+			name:           `all bookmarks`,
+			propsRequested: []string{`creation`},
+			metricNames:    []string{`zfs_bookmark_count`, `zfs_bookmark_newest_timestamp_seconds`, `zfs_bookmark_oldest_timestamp_seconds`},
+			propsResults: []datasetResults{
+				{name: `testpool/home#repl-1`, results: map[string]string{`creation`: `1735689600`}},
+				{name: `testpool/home#repl-2`, results: map[string]string{`creation`: `1735776000`}},
+				{name: `testpool/var#repl-1`, results: map[string]string{`creation`: `1735000000`}},
+			},
+			metricResults: `# HELP zfs_bookmark_count Number of bookmarks of the dataset.
+# TYPE zfs_bookmark_count gauge
+zfs_bookmark_count{name="testpool/home",pool="testpool"} 2
+zfs_bookmark_count{name="testpool/var",pool="testpool"} 1
+# HELP zfs_bookmark_newest_timestamp_seconds The unix timestamp when the newest bookmark of the dataset was created.
+# TYPE zfs_bookmark_newest_timestamp_seconds gauge
+zfs_bookmark_newest_timestamp_seconds{name="testpool/home",pool="testpool"} 1.735776e+09
+zfs_bookmark_newest_timestamp_seconds{name="testpool/var",pool="testpool"} 1.735e+09
+# HELP zfs_bookmark_oldest_timestamp_seconds The unix timestamp when the oldest bookmark of the dataset was created.
+# TYPE zfs_bookmark_oldest_timestamp_seconds gauge
+zfs_bookmark_oldest_timestamp_seconds{name="testpool/home",pool="testpool"} 1.7356896e+09
+zfs_bookmark_oldest_timestamp_seconds{name="testpool/var",pool="testpool"} 1.735e+09
+`,
+		},
+		{
+			name:           `filtered`,
+			propsRequested: []string{`creation`},
+			options: map[string]*string{
+				datasetIncludeOption: stringPointer(`^testpool/home#`),
+				datasetDepthOption:   stringPointer(`1`),
+			},
+			excludes:    []string{`#manual$`},
+			root:        `testpool/home`,
+			depth:       1,
+			metricNames: []string{`zfs_bookmark_count`, `zfs_bookmark_newest_timestamp_seconds`},
+			propsResults: []datasetResults{
+				{name: `testpool/home#repl-1`, results: map[string]string{`creation`: `1735689600`}},
+				{name: `testpool/home#manual`, results: map[string]string{`creation`: `1735779600`}},
+			},
+			metricResults: `# HELP zfs_bookmark_count Number of bookmarks of the dataset.
+# TYPE zfs_bookmark_count gauge
+zfs_bookmark_count{name="testpool/home",pool="testpool"} 1
+# HELP zfs_bookmark_newest_timestamp_seconds The unix timestamp when the newest bookmark of the dataset was created.
+# TYPE zfs_bookmark_newest_timestamp_seconds gauge
+zfs_bookmark_newest_timestamp_seconds{name="testpool/home",pool="testpool"} 1.7356896e+09
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+			config.Excludes = tc.excludes
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsDatasetResults := make([]zfs.DatasetProperties, len(tc.propsResults))
+			for i, propResults := range tc.propsResults {
+				zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+				zfsDatasetProperties.EXPECT().DatasetName().Return(propResults.name).MinTimes(1)
+				zfsDatasetProperties.EXPECT().Properties().Return(propResults.results).MaxTimes(1)
+				zfsDatasetResults[i] = zfsDatasetProperties
+			}
+			zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+			zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetBookmark).Return(zfsDatasets).Times(1)
+			if tc.root != `` {
+				zfsRootDatasets := mock_zfs.NewMockDatasets(ctrl)
+				zfsDatasets.EXPECT().Root(tc.root, tc.depth).Return(zfsRootDatasets).Times(1)
+				zfsDatasets = zfsRootDatasets
+			}
+			zfsDatasets.EXPECT().Properties(gomock.Any(), tc.propsRequested).Return(zfsDatasetResults, nil).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`dataset-bookmark`: {
+					Name:       "dataset-bookmark",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					Options:    tc.options,
+					factory:    newBookmarkCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -1,15 +1,21 @@
 package collector
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/pdf/zfs_exporter/v2/zfs"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 )
 
 const (
@@ -19,8 +25,28 @@ const (
 	helpDefaultStateEnabled  = `enabled`
 	helpDefaultStateDisabled = `disabled`
 
-	subsystemDataset = `dataset`
-	subsystemPool    = `pool`
+	subsystemARC          = `arc`
+	subsystemBookmark     = `bookmark`
+	subsystemDataset      = `dataset`
+	subsystemGroupspace   = `groupspace`
+	subsystemPool         = `pool`
+	subsystemPoolIO       = `pool_io`
+	subsystemProjectspace = `projectspace`
+	subsystemScan         = `scan`
+	subsystemSnapshot     = `snapshot`
+	subsystemTxg          = `txg`
+	subsystemUserspace    = `userspace`
+	subsystemVdev         = `vdev`
+
+	infoValueLabel = `value`
+
+	deadlineOption = `deadline`
+	intervalOption = `interval`
+
+	// propertiesAll selects all available properties
+	propertiesAll = `all`
+	// propertiesSupported selects all properties with first-class support
+	propertiesSupported = `supported`
 
 	propertyUnsupportedDesc = `!!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/pdf/zfs_exporter/issues to have this property supported !!!`
 	propertyUnsupportedMsg  = `Unsupported dataset property, results are likely to be undesirable`
@@ -29,6 +55,7 @@ const (
 
 var (
 	collectorStates        = make(map[string]State)
+	collectorFilters       = make(map[string]string)
 	scrapeDurationDescName = prometheus.BuildFQName(namespace, `scrape`, `collector_duration_seconds`)
 	scrapeDurationDesc     = prometheus.NewDesc(
 		scrapeDurationDescName,
@@ -43,25 +70,141 @@ var (
 		[]string{`collector`},
 		nil,
 	)
+	scrapeLastSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, `scrape`, `collector_last_success_timestamp_seconds`),
+		`zfs_exporter: The unix timestamp of the last successful collector run.`,
+		[]string{`collector`},
+		nil,
+	)
+	scrapeCacheAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, `scrape`, `collector_cache_age_seconds`),
+		`zfs_exporter: Age of the collector data served by the scrape, non-zero when served from the cache.`,
+		[]string{`collector`},
+		nil,
+	)
+	scrapeStaleDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, `scrape`, `collector_stale`),
+		`zfs_exporter: Whether the collector data served by the scrape was served from the cache, as the collector did not complete before the deadline.`,
+		[]string{`collector`},
+		nil,
+	)
+	scrapeKilledDescName = prometheus.BuildFQName(namespace, `scrape`, `collector_killed_commands_total`)
+	scrapeKilledDesc     = prometheus.NewDesc(
+		scrapeKilledDescName,
+		`zfs_exporter: Number of commands killed upon exceeding the collector timeout.`,
+		[]string{`collector`},
+		nil,
+	)
 
 	errUnsupportedProperty = errors.New(`unsupported property`)
 )
 
-type factoryFunc func(l *slog.Logger, c zfs.Client, properties []string) (Collector, error)
+type factoryFunc func(l *slog.Logger, c zfs.Client, properties []string, options map[string]string) (Collector, error)
 
 type transformFunc func(string) (float64, error)
 
 // State holds metadata for managing collector status
 type State struct {
-	Name       string
+	Name          string
+	Enabled       *bool
+	Properties    *string
+	Options       map[string]*string
+	factory       factoryFunc
+	enabledSet    *bool
+	propertiesSet *bool
+	optionsSet    map[string]*bool
+}
+
+// deadline returns the deadline option of the collector, or the provided default if unset
+func (s State) deadline(defaultValue time.Duration) (time.Duration, error) {
+	return s.durationOption(deadlineOption, defaultValue)
+}
+
+// interval returns the interval option of the collector, or the provided default if unset
+func (s State) interval(defaultValue time.Duration) (time.Duration, error) {
+	return s.durationOption(intervalOption, defaultValue)
+}
+
+func (s State) durationOption(option string, defaultValue time.Duration) (time.Duration, error) {
+	v, ok := s.Options[option]
+	if !ok || *v == `` {
+		return defaultValue, nil
+	}
+	d, err := model.ParseDuration(*v)
+	if err != nil {
+		return defaultValue, err
+	}
+	return time.Duration(d), nil
+}
+
+// options returns the current values of the collector options
+func (s State) options() map[string]string {
+	options := make(map[string]string, len(s.Options))
+	for k, v := range s.Options {
+		options[k] = *v
+	}
+	return options
+}
+
+// CollectorConfig holds collector settings loaded from a configuration file. Unset fields retain the value of the
+// corresponding flag.
+type CollectorConfig struct {
 	Enabled    *bool
-	Properties *string
-	factory    factoryFunc
+	Properties []string
+	Options    map[string]string
+}
+
+// ConfigureCollectors returns a copy of the registered collector states, with the provided configuration applied to
+// any settings that were not explicitly set by command-line flags, which take precedence.
+func ConfigureCollectors(configs map[string]CollectorConfig) (map[string]State, error) {
+	for name := range configs {
+		if _, ok := collectorStates[name]; !ok {
+			return nil, fmt.Errorf("unknown collector: %s", name)
+		}
+	}
+
+	result := make(map[string]State, len(collectorStates))
+	for name, state := range collectorStates {
+		config := configs[name]
+
+		enabled := *state.Enabled
+		if config.Enabled != nil && !*state.enabledSet {
+			enabled = *config.Enabled
+		}
+		properties := *state.Properties
+		if config.Properties != nil && !*state.propertiesSet {
+			properties = strings.Join(config.Properties, `,`)
+		}
+		options := make(map[string]*string, len(state.Options))
+		for k, v := range state.Options {
+			value := *v
+			options[k] = &value
+		}
+		for k, v := range config.Options {
+			if _, ok := state.Options[k]; !ok {
+				return nil, fmt.Errorf("unknown option for collector %s: %s", name, k)
+			}
+			if !*state.optionsSet[k] {
+				value := v
+				options[k] = &value
+			}
+		}
+
+		result[name] = State{
+			Name:       name,
+			Enabled:    &enabled,
+			Properties: &properties,
+			Options:    options,
+			factory:    state.factory,
+		}
+	}
+
+	return result, nil
 }
 
 // Collector defines the minimum functionality for registering a collector
 type Collector interface {
-	update(ch chan<- metric, pools []string, excludes regexpCollection) error
+	update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error
 	describe(ch chan<- *prometheus.Desc)
 }
 
@@ -75,9 +218,13 @@ type property struct {
 	desc      *prometheus.Desc
 	transform transformFunc
 	kind      prometheus.ValueType
+	info      bool
 }
 
 func (p property) push(ch chan<- metric, value string, labelValues ...string) error {
+	if p.info {
+		return p.pushInfo(ch, value, labelValues...)
+	}
 	v, err := p.transform(value)
 	if err != nil {
 		return err
@@ -95,6 +242,25 @@ func (p property) push(ch chan<- metric, value string, labelValues ...string) er
 	return nil
 }
 
+// pushInfo sends an info-style metric, with the value as a label and a sample of 1. Values that are not applicable to
+// the dataset (`-`) are skipped.
+func (p property) pushInfo(ch chan<- metric, value string, labelValues ...string) error {
+	if value == `-` {
+		return nil
+	}
+	ch <- metric{
+		name: expandMetricName(p.name, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(
+			p.desc,
+			prometheus.GaugeValue,
+			1,
+			append(append(make([]string, 0, len(labelValues)+1), labelValues...), value)...,
+		),
+	}
+
+	return nil
+}
+
 type propertyStore struct {
 	defaultSubsystem string
 	defaultLabels    []string
@@ -117,6 +283,93 @@ func (p *propertyStore) find(name string) (property, error) {
 	return prop, nil
 }
 
+// pushSelected sends the metric for a property returned by the ZFS tools, as selected by the requested properties.
+// Unsupported properties that were selected by `all` or a pattern, rather than explicitly, are skipped if their value
+// is not numeric.
+func (p *propertyStore) pushSelected(l *slog.Logger, collector string, requested []string, ch chan<- metric, name, value string, labelValues ...string) error {
+	explicit := slices.Contains(requested, name)
+	prop, err := p.find(name)
+	if err != nil {
+		if explicit {
+			l.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, collector, `property`, name, `err`, err)
+		} else {
+			l.Debug(propertyUnsupportedMsg, `collector`, collector, `property`, name, `err`, err)
+		}
+	}
+	if err = prop.push(ch, value, labelValues...); err != nil {
+		if !explicit {
+			l.Debug(`Skipping non-numeric property`, `collector`, collector, `property`, name, `value`, value)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// describeSelected sends the descriptors of the supported properties matched by the requested properties, including
+// `all` and patterns
+func (p *propertyStore) describeSelected(l *slog.Logger, collector string, requested []string, ch chan<- *prometheus.Desc) {
+	for _, k := range expandProperties(requested, p.names(), false) {
+		prop, err := p.find(k)
+		if err != nil {
+			l.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, collector, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+// names returns the sorted names of the supported properties
+func (p *propertyStore) names() []string {
+	return slices.Sorted(maps.Keys(p.store))
+}
+
+// isPropertyPattern reports whether the property is a pattern, as accepted by path.Match (ie - `usedby*`)
+func isPropertyPattern(prop string) bool {
+	return strings.ContainsAny(prop, `*?[`)
+}
+
+// isDynamicProperty reports whether the property is resolved against the properties returned by the ZFS tools
+func isDynamicProperty(prop string) bool {
+	return prop == propertiesAll || isPropertyPattern(prop)
+}
+
+// expandProperties resolves the `supported` keyword in the property list to the supported property names. Where
+// dynamic, `all` and patterns are retained to be resolved against the properties returned by the ZFS tools, otherwise
+// they are also resolved against the supported property names.
+func expandProperties(props []string, supported []string, dynamic bool) []string {
+	result := make([]string, 0, len(props))
+	seen := make(map[string]struct{}, len(props))
+	add := func(names ...string) {
+		for _, name := range names {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			result = append(result, name)
+		}
+	}
+	for _, prop := range props {
+		switch {
+		case prop == propertiesSupported:
+			add(supported...)
+		case dynamic && isDynamicProperty(prop):
+			add(prop)
+		case prop == propertiesAll:
+			add(supported...)
+		case isPropertyPattern(prop):
+			for _, name := range supported {
+				if ok, _ := path.Match(prop, name); ok {
+					add(name)
+				}
+			}
+		default:
+			add(prop)
+		}
+	}
+	return result
+}
+
 func registerCollector(collector string, isDefaultEnabled bool, defaultProps string, factory factoryFunc) {
 	helpDefaultState := helpDefaultStateDisabled
 	if isDefaultEnabled {
@@ -128,16 +381,56 @@ func registerCollector(collector string, isDefaultEnabled bool, defaultProps str
 	enabledDefaultValue := strconv.FormatBool(isDefaultEnabled)
 
 	propsFlagName := fmt.Sprintf("properties.%s", collector)
-	propsFlagHelp := fmt.Sprintf("Properties to include for the %s collector, comma-separated.", collector)
+	propsFlagHelp := fmt.Sprintf("Properties to include for the %s collector, comma-separated. May include '%s', '%s', or patterns (e.g. 'usedby*').", collector, propertiesAll, propertiesSupported)
 
-	enabledFlag := kingpin.Flag(enabledFlagName, enabledFlagHelp).Default(enabledDefaultValue).Bool()
-	propsFlag := kingpin.Flag(propsFlagName, propsFlagHelp).Default(defaultProps).String()
+	enabledSet := new(bool)
+	propsSet := new(bool)
+	enabledFlag := kingpin.Flag(enabledFlagName, enabledFlagHelp).Default(enabledDefaultValue).IsSetByUser(enabledSet).Bool()
+	propsFlag := kingpin.Flag(propsFlagName, propsFlagHelp).Default(defaultProps).IsSetByUser(propsSet).String()
 
 	collectorStates[collector] = State{
-		Enabled:    enabledFlag,
-		Properties: propsFlag,
-		factory:    factory,
+		Name:          collector,
+		Enabled:       enabledFlag,
+		Properties:    propsFlag,
+		factory:       factory,
+		enabledSet:    enabledSet,
+		propertiesSet: propsSet,
+	}
+	registerCollectorOption(
+		collector,
+		deadlineOption,
+		fmt.Sprintf("Maximum duration that the %s collector should run before returning cached data (default: --deadline).", collector),
+		``,
+	)
+	registerCollectorOption(
+		collector,
+		intervalOption,
+		fmt.Sprintf("Minimum interval between runs of the %s collector, cached data is returned when it is not due (default: every scrape, or --collector.poll-interval when polling).", collector),
+		``,
+	)
+}
+
+// registerCollectorOption registers a flag for a collector-specific option, named `collector.<collector>.<option>`.
+// The collector must already be registered.
+func registerCollectorOption(collector, option, help, defaultValue string) {
+	flagName := fmt.Sprintf("collector.%s.%s", collector, option)
+	flagSet := new(bool)
+	flag := kingpin.Flag(flagName, help).Default(defaultValue).IsSetByUser(flagSet).String()
+
+	state := collectorStates[collector]
+	if state.Options == nil {
+		state.Options = make(map[string]*string)
+		state.optionsSet = make(map[string]*bool)
 	}
+	state.Options[option] = flag
+	state.optionsSet[option] = flagSet
+	collectorStates[collector] = state
+}
+
+// registerCollectorFilter shares the dataset filter options of the source collector with the collector, such that
+// both select the same datasets. The collectors must already be registered.
+func registerCollectorFilter(collector, source string) {
+	collectorFilters[collector] = source
 }
 
 func expandMetricName(prefix string, context ...string) string {
@@ -153,3 +446,15 @@ func newProperty(subsystem, metricName, helpText string, transform transformFunc
 		kind:      kind,
 	}
 }
+
+// newInfoProperty creates a property for a string-valued ZFS property, exposed as an info metric with the property
+// value in the `value` label.
+func newInfoProperty(subsystem, metricName, helpText string, labels ...string) property {
+	name := prometheus.BuildFQName(namespace, subsystem, metricName)
+	return property{
+		name: name,
+		desc: prometheus.NewDesc(name, helpText, append(append(make([]string, 0, len(labels)+1), labels...), infoValueLabel), nil),
+		kind: prometheus.GaugeValue,
+		info: true,
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"reflect"
+	"testing"
 	"time"
 
 	"github.com/pdf/zfs_exporter/v2/zfs"
@@ -44,3 +46,83 @@ func stringPointer(s string) *string {
 func boolPointer(b bool) *bool {
 	return &b
 }
+
+func TestConfigureCollectors(t *testing.T) {
+	states, err := ConfigureCollectors(map[string]CollectorConfig{
+		`pool`: {
+			Enabled:    boolPointer(true),
+			Properties: []string{`health`, `size`},
+		},
+		`snapshot-summary`: {
+			Options: map[string]string{snapshotSummaryGroupOption: `_(daily)$`},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := states[`pool`]; !*state.Enabled || *state.Properties != `health,size` {
+		t.Errorf("unexpected pool state: enabled=%t properties=%s", *state.Enabled, *state.Properties)
+	}
+	if group := states[`snapshot-summary`].options()[snapshotSummaryGroupOption]; group != `_(daily)$` {
+		t.Errorf("unexpected snapshot-summary group: %s", group)
+	}
+	if states[`pool`].Enabled == collectorStates[`pool`].Enabled {
+		t.Error(`expected configured state to be a copy of the registered state`)
+	}
+
+	for name, config := range map[string]CollectorConfig{
+		`nonexistent`:      {},
+		`snapshot-summary`: {Options: map[string]string{`nonexistent`: ``}},
+	} {
+		if _, err = ConfigureCollectors(map[string]CollectorConfig{name: config}); err == nil {
+			t.Errorf("expected error for collector %s, got nil", name)
+		}
+	}
+}
+
+func TestExpandProperties(t *testing.T) {
+	supported := []string{`used`, `usedbychildren`, `usedbydataset`, `written`}
+	testCases := []struct {
+		name     string
+		props    []string
+		dynamic  bool
+		expected []string
+	}{
+		{
+			name:     `explicit`,
+			props:    []string{`written`, `unsupported`},
+			expected: []string{`written`, `unsupported`},
+		},
+		{
+			name:     `supported`,
+			props:    []string{`written`, `supported`},
+			expected: []string{`written`, `used`, `usedbychildren`, `usedbydataset`},
+		},
+		{
+			name:     `all`,
+			props:    []string{`all`},
+			expected: supported,
+		},
+		{
+			name:     `pattern`,
+			props:    []string{`usedby*`, `written`},
+			expected: []string{`usedbychildren`, `usedbydataset`, `written`},
+		},
+		{
+			name:     `dynamic`,
+			props:    []string{`supported`, `all`, `usedby*`},
+			dynamic:  true,
+			expected: []string{`used`, `usedbychildren`, `usedbydataset`, `written`, `all`, `usedby*`},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if result := expandProperties(tc.props, supported, tc.dynamic); !reflect.DeepEqual(result, tc.expected) {
+				t.Fatalf("unexpected properties: %v, expected %v", result, tc.expected)
+			}
+		})
+	}
+}
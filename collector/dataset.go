@@ -1,8 +1,15 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/pdf/zfs_exporter/v2/zfs"
@@ -10,12 +17,20 @@ import (
 )
 
 const (
+	// datasetUserPropsOption holds the user properties nominated by ZFSConfig.UserProperties, rather than a flag
+	datasetUserPropsOption = `user-properties`
+	datasetIncludeOption   = `include`
+	datasetExcludeOption   = `exclude`
+	datasetDepthOption     = `depth`
+
 	defaultFilesystemProps = `available,logicalused,quota,referenced,used,usedbydataset,written`
 	defaultSnapshotProps   = `logicalused,referenced,used,written`
 	defaultVolumeProps     = `available,logicalused,referenced,used,usedbydataset,volsize,written`
 )
 
 var (
+	datasetInfoDescName = prometheus.BuildFQName(namespace, subsystemDataset, `info`)
+
 	datasetLabels     = []string{`name`, `pool`, `type`}
 	datasetProperties = propertyStore{
 		defaultSubsystem: subsystemDataset,
@@ -181,41 +196,350 @@ var (
 				prometheus.GaugeValue,
 				datasetLabels...,
 			),
+			`atime`: newInfoProperty(
+				subsystemDataset,
+				`atime_info`,
+				`Whether access times are updated when files are read.`,
+				datasetLabels...,
+			),
+			`canmount`: newInfoProperty(
+				subsystemDataset,
+				`canmount_info`,
+				`Whether the filesystem can be mounted.`,
+				datasetLabels...,
+			),
+			`checksum`: newInfoProperty(
+				subsystemDataset,
+				`checksum_info`,
+				`The checksum algorithm used to verify data integrity.`,
+				datasetLabels...,
+			),
+			`compression`: newInfoProperty(
+				subsystemDataset,
+				`compression_info`,
+				`The compression algorithm used for this dataset.`,
+				datasetLabels...,
+			),
+			`dedup`: newInfoProperty(
+				subsystemDataset,
+				`dedup_info`,
+				`The deduplication setting for this dataset.`,
+				datasetLabels...,
+			),
+			`encryption`: newInfoProperty(
+				subsystemDataset,
+				`encryption_info`,
+				`The encryption suite used for this dataset.`,
+				datasetLabels...,
+			),
+			`keystatus`: newInfoProperty(
+				subsystemDataset,
+				`keystatus_info`,
+				`Whether the encryption key for this dataset is available.`,
+				datasetLabels...,
+			),
+			`logbias`: newInfoProperty(
+				subsystemDataset,
+				`logbias_info`,
+				`How synchronous requests are handled for this dataset.`,
+				datasetLabels...,
+			),
+			`mountpoint`: newInfoProperty(
+				subsystemDataset,
+				`mountpoint_info`,
+				`The mount point used for this filesystem.`,
+				datasetLabels...,
+			),
+			`origin`: newInfoProperty(
+				subsystemDataset,
+				`origin_info`,
+				`The snapshot from which this clone was created.`,
+				datasetLabels...,
+			),
+			`primarycache`: newInfoProperty(
+				subsystemDataset,
+				`primarycache_info`,
+				`What is cached in the primary cache (ARC) for this dataset.`,
+				datasetLabels...,
+			),
+			`readonly`: newInfoProperty(
+				subsystemDataset,
+				`readonly_info`,
+				`Whether this dataset can be modified.`,
+				datasetLabels...,
+			),
+			`recordsize`: newInfoProperty(
+				subsystemDataset,
+				`recordsize_info`,
+				`The suggested block size in bytes for files in this filesystem.`,
+				datasetLabels...,
+			),
+			`secondarycache`: newInfoProperty(
+				subsystemDataset,
+				`secondarycache_info`,
+				`What is cached in the secondary cache (L2ARC) for this dataset.`,
+				datasetLabels...,
+			),
+			`sync`: newInfoProperty(
+				subsystemDataset,
+				`sync_info`,
+				`The behaviour of synchronous requests for this dataset.`,
+				datasetLabels...,
+			),
+			`volblocksize`: newInfoProperty(
+				subsystemDataset,
+				`volblocksize_info`,
+				`The block size in bytes of this volume.`,
+				datasetLabels...,
+			),
+			`xattr`: newInfoProperty(
+				subsystemDataset,
+				`xattr_info`,
+				`How extended attributes are stored for this filesystem.`,
+				datasetLabels...,
+			),
 		},
 	}
 )
 
+// datasetDerivedMetric is a metric derived from a property value relative to a limit, emitted only when both properties
+// are available and the limit is set
+type datasetDerivedMetric struct {
+	name   string
+	desc   *prometheus.Desc
+	value  string
+	limit  string
+	derive func(value, limit float64) float64
+}
+
+func newDatasetDerivedMetric(metricName, helpText, value, limit string, derive func(value, limit float64) float64) datasetDerivedMetric {
+	name := prometheus.BuildFQName(namespace, subsystemDataset, metricName)
+	return datasetDerivedMetric{
+		name:   name,
+		desc:   prometheus.NewDesc(name, helpText, datasetLabels, nil),
+		value:  value,
+		limit:  limit,
+		derive: derive,
+	}
+}
+
+var datasetDerivedMetrics = []datasetDerivedMetric{
+	newDatasetDerivedMetric(
+		`quota_utilisation_ratio`,
+		`Ratio of the quota of the dataset consumed by the dataset and all its descendents, only present when a quota is set.`,
+		`used`,
+		`quota`,
+		func(used, quota float64) float64 { return used / quota },
+	),
+	newDatasetDerivedMetric(
+		`refquota_utilisation_ratio`,
+		`Ratio of the referenced quota of the dataset consumed by the dataset, only present when a referenced quota is set.`,
+		`referenced`,
+		`refquota`,
+		func(referenced, refquota float64) float64 { return referenced / refquota },
+	),
+	newDatasetDerivedMetric(
+		`reservation_excess_bytes`,
+		`Amount of space in bytes consumed by the dataset and all its descendents in excess of the reservation (ie - used minus reservation, or zero when within the reservation), which is not guaranteed, only present when a reservation is set.`,
+		`used`,
+		`reservation`,
+		func(used, reservation float64) float64 { return math.Max(used-reservation, 0) },
+	),
+}
+
 func init() {
 	registerCollector(`dataset-filesystem`, defaultEnabled, defaultFilesystemProps, newFilesystemCollector)
 	registerCollector(`dataset-snapshot`, defaultDisabled, defaultSnapshotProps, newSnapshotCollector)
 	registerCollector(`dataset-volume`, defaultEnabled, defaultVolumeProps, newVolumeCollector)
+
+	for _, collector := range []string{`dataset-filesystem`, `dataset-snapshot`, `dataset-volume`} {
+		registerCollectorOption(
+			collector,
+			datasetIncludeOption,
+			fmt.Sprintf("Only include datasets that match the provided regex for the %s collector (e.g. '^tank/backup/'). When anchored with '^', only the datasets below the longest dataset name in the literal prefix are queried, which must exist.", collector),
+			``,
+		)
+		registerCollectorOption(
+			collector,
+			datasetExcludeOption,
+			fmt.Sprintf("Exclude datasets that match the provided regex for the %s collector, in addition to --exclude.", collector),
+			``,
+		)
+		registerCollectorOption(
+			collector,
+			datasetDepthOption,
+			fmt.Sprintf("Only include datasets at most this many levels below the pool root for the %s collector, where snapshots are at the level of their dataset (default: unlimited).", collector),
+			``,
+		)
+	}
+}
+
+// userPropertyLabel returns a valid label name for a user property, ie - `com.example:owner` becomes
+// `com_example_owner`.
+func userPropertyLabel(name string) string {
+	label := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if label[0] >= '0' && label[0] <= '9' {
+		label = `_` + label
+	}
+	return label
+}
+
+// datasetFilter selects the datasets collected by a dataset collector
+type datasetFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+	// prefix is the literal prefix of all datasets matching an anchored include
+	prefix string
+	depth  int
+}
+
+// match reports whether the dataset is selected by the filter
+func (f datasetFilter) match(name string) bool {
+	if f.depth >= 0 && datasetDepth(name) > f.depth {
+		return false
+	}
+	if f.include != nil && !f.include.MatchString(name) {
+		return false
+	}
+	return f.exclude == nil || !f.exclude.MatchString(name)
+}
+
+// root returns the dataset below which all datasets selected by the filter within the base dataset (ie - the pool)
+// reside, and the depth below that dataset to query (unlimited if negative). If no datasets within the base may be
+// selected, ok is false.
+func (f datasetFilter) root(base string, kind zfs.DatasetKind) (root string, depth int, ok bool) {
+	switch {
+	case strings.HasPrefix(base, f.prefix):
+		root = base
+	case strings.HasPrefix(f.prefix, base+`/`), strings.HasPrefix(f.prefix, base+`@`), strings.HasPrefix(f.prefix, base+`#`):
+		root = f.prefix[:strings.LastIndexAny(f.prefix, `/@#`)]
+	default:
+		return ``, 0, false
+	}
+	if f.depth < 0 {
+		return root, -1, true
+	}
+	depth = f.depth - datasetDepth(root)
+	if depth < 0 {
+		return ``, 0, false
+	}
+	if kind == zfs.DatasetSnapshot || kind == zfs.DatasetBookmark {
+		depth++
+	}
+	return root, depth, true
+}
+
+// datasets returns the requested properties of the datasets of the kind within the pool that are selected by the
+// filter, querying below each of the roots
+func (f datasetFilter) datasets(ctx context.Context, client zfs.Client, pool string, kind zfs.DatasetKind, roots []string, excludes regexpCollection, props ...string) ([]zfs.DatasetProperties, error) {
+	var result []zfs.DatasetProperties
+	for _, base := range roots {
+		root, depth, ok := f.root(base, kind)
+		if !ok {
+			continue
+		}
+		datasets := client.Datasets(pool, kind)
+		if root != pool || depth >= 0 {
+			datasets = datasets.Root(root, depth)
+		}
+		found, err := datasets.Properties(ctx, props...)
+		if err != nil {
+			return nil, err
+		}
+		for _, dataset := range found {
+			if name := dataset.DatasetName(); excludes.MatchString(name) || !f.match(name) {
+				continue
+			}
+			result = append(result, dataset)
+		}
+	}
+
+	return result, nil
+}
+
+// datasetDepth returns the number of levels below the pool root of the dataset, or the dataset of a snapshot or bookmark
+func datasetDepth(name string) int {
+	if i := strings.IndexAny(name, `@#`); i >= 0 {
+		name = name[:i]
+	}
+	return strings.Count(name, `/`)
+}
+
+// anchoredPrefix returns the literal prefix of the regex, if it is anchored to the start of the input
+func anchoredPrefix(expr string) string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return ``
+	}
+	re = re.Simplify()
+	if re.Op != syntax.OpConcat || len(re.Sub) < 2 || re.Sub[0].Op != syntax.OpBeginText {
+		return ``
+	}
+	if lit := re.Sub[1]; lit.Op == syntax.OpLiteral && lit.Flags&syntax.FoldCase == 0 {
+		return string(lit.Rune)
+	}
+	return ``
+}
+
+// newDatasetFilter returns the filter configured by the collector options
+func newDatasetFilter(options map[string]string) (datasetFilter, error) {
+	filter := datasetFilter{depth: -1}
+	var err error
+	if v := options[datasetIncludeOption]; v != `` {
+		if filter.include, err = regexp.Compile(v); err != nil {
+			return filter, fmt.Errorf("invalid %s option: %w", datasetIncludeOption, err)
+		}
+		filter.prefix = anchoredPrefix(v)
+	}
+	if v := options[datasetExcludeOption]; v != `` {
+		if filter.exclude, err = regexp.Compile(v); err != nil {
+			return filter, fmt.Errorf("invalid %s option: %w", datasetExcludeOption, err)
+		}
+	}
+	if v := options[datasetDepthOption]; v != `` {
+		if filter.depth, err = strconv.Atoi(v); err != nil || filter.depth < 0 {
+			return filter, fmt.Errorf("invalid %s option, must be a non-negative integer: %s", datasetDepthOption, v)
+		}
+	}
+	return filter, nil
 }
 
 type datasetCollector struct {
-	kind   zfs.DatasetKind
-	log    *slog.Logger
-	client zfs.Client
-	props  []string
+	kind      zfs.DatasetKind
+	log       *slog.Logger
+	client    zfs.Client
+	props     []string
+	userProps map[string]struct{}
+	infoDesc  *prometheus.Desc
+	infoProps []string
+	filter    datasetFilter
 }
 
 func (c *datasetCollector) describe(ch chan<- *prometheus.Desc) {
-	for _, k := range c.props {
-		prop, err := datasetProperties.find(k)
-		if err != nil {
-			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, c.kind, `property`, k, `err`, err)
-			continue
+	if c.infoDesc != nil {
+		ch <- c.infoDesc
+	}
+	datasetProperties.describeSelected(c.log, string(c.kind), c.props, ch)
+	selected := expandProperties(c.props, datasetProperties.names(), false)
+	for _, d := range datasetDerivedMetrics {
+		if slices.Contains(selected, d.value) && slices.Contains(selected, d.limit) {
+			ch <- d.desc
 		}
-		ch <- prop.desc
 	}
 }
 
-func (c *datasetCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+func (c *datasetCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(pools))
 	for _, pool := range pools {
 		wg.Add(1)
 		go func(pool string) {
-			if err := c.updatePoolMetrics(ch, pool, excludes); err != nil {
+			if err := c.updatePoolMetrics(ctx, ch, pool, roots.pool(pool), excludes); err != nil {
 				errChan <- err
 			}
 			wg.Done()
@@ -231,17 +555,12 @@ func (c *datasetCollector) update(ch chan<- metric, pools []string, excludes reg
 	}
 }
 
-func (c *datasetCollector) updatePoolMetrics(ch chan<- metric, pool string, excludes regexpCollection) error {
-	datasets := c.client.Datasets(pool, c.kind)
-	props, err := datasets.Properties(c.props...)
+func (c *datasetCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, roots []string, excludes regexpCollection) error {
+	datasets, err := c.filter.datasets(ctx, c.client, pool, c.kind, roots, excludes, c.requestProps()...)
 	if err != nil {
 		return err
 	}
-
-	for _, dataset := range props {
-		if excludes.MatchString(dataset.DatasetName()) {
-			continue
-		}
+	for _, dataset := range datasets {
 		if err = c.updateDatasetMetrics(ch, pool, dataset); err != nil {
 			return err
 		}
@@ -250,40 +569,143 @@ func (c *datasetCollector) updatePoolMetrics(ch chan<- metric, pool string, excl
 	return nil
 }
 
+// requestProps returns the properties to request for each dataset, including any nominated user properties
+func (c *datasetCollector) requestProps() []string {
+	if len(c.infoProps) == 0 {
+		return c.props
+	}
+	return append(append(make([]string, 0, len(c.props)+len(c.infoProps)), c.props...), c.infoProps...)
+}
+
 func (c *datasetCollector) updateDatasetMetrics(ch chan<- metric, pool string, dataset zfs.DatasetProperties) error {
 	labelValues := []string{dataset.DatasetName(), pool, string(c.kind)}
 
-	for k, v := range dataset.Properties() {
-		prop, err := datasetProperties.find(k)
+	properties := dataset.Properties()
+	if c.infoDesc != nil {
+		infoLabelValues := append(make([]string, 0, len(labelValues)+len(c.infoProps)), labelValues...)
+		for _, k := range c.infoProps {
+			v := properties[k]
+			// User properties that are not set are reported as `-`
+			if v == `-` {
+				v = ``
+			}
+			infoLabelValues = append(infoLabelValues, v)
+		}
+		ch <- metric{
+			name:       expandMetricName(datasetInfoDescName, labelValues...),
+			prometheus: prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, infoLabelValues...),
+		}
+	}
+
+	for k, v := range properties {
+		if _, ok := c.userProps[k]; ok {
+			continue
+		}
+		// User properties are not selected by `all` or patterns, nominate them as labels of the info metric instead
+		if strings.Contains(k, `:`) && !slices.Contains(c.props, k) {
+			continue
+		}
+		if err := datasetProperties.pushSelected(c.log, string(c.kind), c.props, ch, k, v, labelValues...); err != nil {
+			return err
+		}
+	}
+
+	return pushDerivedMetrics(ch, properties, labelValues...)
+}
+
+// pushDerivedMetrics sends the metrics derived from the dataset properties, where available
+func pushDerivedMetrics(ch chan<- metric, properties map[string]string, labelValues ...string) error {
+	for _, d := range datasetDerivedMetrics {
+		value, ok := properties[d.value]
+		if !ok {
+			continue
+		}
+		limit, ok := properties[d.limit]
+		if !ok {
+			continue
+		}
+		l, err := transformNumeric(limit)
 		if err != nil {
-			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, c.kind, `property`, k, `err`, err)
+			return err
+		}
+		// A limit of zero indicates that it is not set
+		if l <= 0 {
+			continue
 		}
-		if err = prop.push(ch, v, labelValues...); err != nil {
+		v, err := transformNumeric(value)
+		if err != nil {
 			return err
 		}
+		ch <- metric{
+			name:       expandMetricName(d.name, labelValues...),
+			prometheus: prometheus.MustNewConstMetric(d.desc, prometheus.GaugeValue, d.derive(v, l), labelValues...),
+		}
 	}
 
 	return nil
 }
 
-func newDatasetCollector(kind zfs.DatasetKind, l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
+func newDatasetCollector(kind zfs.DatasetKind, l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
 	switch kind {
 	case zfs.DatasetFilesystem, zfs.DatasetSnapshot, zfs.DatasetVolume:
 	default:
 		return nil, fmt.Errorf("unknown dataset type: %s", kind)
 	}
 
-	return &datasetCollector{kind: kind, log: l, client: c, props: props}, nil
+	filter, err := newDatasetFilter(options)
+	if err != nil {
+		return nil, err
+	}
+	collector := &datasetCollector{kind: kind, log: l, client: c, props: expandProperties(props, datasetProperties.names(), true), userProps: make(map[string]struct{}), filter: filter}
+	userProps := datasetUserProperties(options)
+	if len(userProps) == 0 {
+		return collector, nil
+	}
+
+	labels := append([]string{}, datasetLabels...)
+	seen := make(map[string]string, len(userProps))
+	for _, k := range userProps {
+		if !strings.Contains(k, `:`) {
+			return nil, fmt.Errorf("invalid user property, name must contain a colon: %s", k)
+		}
+		if _, ok := collector.userProps[k]; ok {
+			continue
+		}
+		label := userPropertyLabel(k)
+		if other, ok := seen[label]; ok {
+			return nil, fmt.Errorf("user properties %s and %s map to the same label: %s", other, k, label)
+		}
+		seen[label] = k
+		collector.userProps[k] = struct{}{}
+		collector.infoProps = append(collector.infoProps, k)
+		labels = append(labels, label)
+	}
+	collector.infoDesc = prometheus.NewDesc(
+		datasetInfoDescName,
+		`Information about the dataset, with nominated user properties as labels.`,
+		labels,
+		nil,
+	)
+
+	return collector, nil
+}
+
+// datasetUserProperties returns the user properties nominated for the zfs_dataset_info metric
+func datasetUserProperties(options map[string]string) []string {
+	if options[datasetUserPropsOption] == `` {
+		return nil
+	}
+	return strings.Split(options[datasetUserPropsOption], `,`)
 }
 
-func newFilesystemCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
-	return newDatasetCollector(zfs.DatasetFilesystem, l, c, props)
+func newFilesystemCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return newDatasetCollector(zfs.DatasetFilesystem, l, c, props, options)
 }
 
-func newSnapshotCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
-	return newDatasetCollector(zfs.DatasetSnapshot, l, c, props)
+func newSnapshotCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return newDatasetCollector(zfs.DatasetSnapshot, l, c, props, options)
 }
 
-func newVolumeCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
-	return newDatasetCollector(zfs.DatasetVolume, l, c, props)
+func newVolumeCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return newDatasetCollector(zfs.DatasetVolume, l, c, props, options)
 }
@@ -22,6 +22,7 @@ func TestDatsetMetrics(t *testing.T) {
 		pools          []string
 		explicitPools  []string
 		propsRequested []string
+		userProps      []string
 		metricNames    []string
 		propsResults   map[string][]datasetResults
 		metricResults  string
@@ -209,6 +210,84 @@ zfs_dataset_available_bytes{name="testpool/test",pool="testpool",type="volume"}
 			metricResults: `# HELP zfs_dataset_unsupported !!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/pdf/zfs_exporter/issues to have this property supported !!!
 # TYPE zfs_dataset_unsupported gauge
 zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"} 1024
+`,
+		},
+		{
+			name:           `info properties`,
+			kinds:          []zfs.DatasetKind{zfs.DatasetFilesystem},
+			pools:          []string{`testpool`},
+			propsRequested: []string{`compression`, `encryption`, `mountpoint`, `origin`, `recordsize`},
+			metricNames:    []string{`zfs_dataset_compression_info`, `zfs_dataset_encryption_info`, `zfs_dataset_mountpoint_info`, `zfs_dataset_origin_info`, `zfs_dataset_recordsize_info`},
+			propsResults: map[string][]datasetResults{
+				`testpool`: {
+					{
+						name: `testpool/test`,
+						results: map[string]string{
+							`compression`: `lz4`,
+							`encryption`:  `off`,
+							`mountpoint`:  `/srv/test`,
+							`origin`:      `-`,
+							`recordsize`:  `131072`,
+						},
+					},
+					{
+						name: `testpool/clone`,
+						results: map[string]string{
+							`compression`: `zstd`,
+							`encryption`:  `aes-256-gcm`,
+							`mountpoint`:  `/srv/clone`,
+							`origin`:      `testpool/test@base`,
+							`recordsize`:  `1048576`,
+						},
+					},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_compression_info The compression algorithm used for this dataset.
+# TYPE zfs_dataset_compression_info gauge
+zfs_dataset_compression_info{name="testpool/clone",pool="testpool",type="filesystem",value="zstd"} 1
+zfs_dataset_compression_info{name="testpool/test",pool="testpool",type="filesystem",value="lz4"} 1
+# HELP zfs_dataset_encryption_info The encryption suite used for this dataset.
+# TYPE zfs_dataset_encryption_info gauge
+zfs_dataset_encryption_info{name="testpool/clone",pool="testpool",type="filesystem",value="aes-256-gcm"} 1
+zfs_dataset_encryption_info{name="testpool/test",pool="testpool",type="filesystem",value="off"} 1
+# HELP zfs_dataset_mountpoint_info The mount point used for this filesystem.
+# TYPE zfs_dataset_mountpoint_info gauge
+zfs_dataset_mountpoint_info{name="testpool/clone",pool="testpool",type="filesystem",value="/srv/clone"} 1
+zfs_dataset_mountpoint_info{name="testpool/test",pool="testpool",type="filesystem",value="/srv/test"} 1
+# HELP zfs_dataset_origin_info The snapshot from which this clone was created.
+# TYPE zfs_dataset_origin_info gauge
+zfs_dataset_origin_info{name="testpool/clone",pool="testpool",type="filesystem",value="testpool/test@base"} 1
+# HELP zfs_dataset_recordsize_info The suggested block size in bytes for files in this filesystem.
+# TYPE zfs_dataset_recordsize_info gauge
+zfs_dataset_recordsize_info{name="testpool/clone",pool="testpool",type="filesystem",value="1048576"} 1
+zfs_dataset_recordsize_info{name="testpool/test",pool="testpool",type="filesystem",value="131072"} 1
+`,
+		},
+		{
+			name:           `user properties`,
+			kinds:          []zfs.DatasetKind{zfs.DatasetFilesystem},
+			pools:          []string{`testpool`},
+			propsRequested: []string{`used`},
+			userProps:      []string{`com.example:owner`, `com.example:tier`},
+			metricNames:    []string{`zfs_dataset_info`, `zfs_dataset_used_bytes`},
+			propsResults: map[string][]datasetResults{
+				`testpool`: {
+					{
+						name: `testpool/test`,
+						results: map[string]string{
+							`used`:              `1024`,
+							`com.example:owner`: `storage-team`,
+							`com.example:tier`:  `-`,
+						},
+					},
+				},
+			},
+			metricResults: `# HELP zfs_dataset_info Information about the dataset, with nominated user properties as labels.
+# TYPE zfs_dataset_info gauge
+zfs_dataset_info{com_example_owner="storage-team",com_example_tier="",name="testpool/test",pool="testpool",type="filesystem"} 1
+# HELP zfs_dataset_used_bytes The amount of space in bytes consumed by this dataset and all its descendents.
+# TYPE zfs_dataset_used_bytes gauge
+zfs_dataset_used_bytes{name="testpool/test",pool="testpool",type="filesystem"} 1024
 `,
 		},
 	}
@@ -223,8 +302,9 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 			if tc.explicitPools != nil {
 				config.Pools = tc.explicitPools
 			}
+			config.UserProperties = tc.userProps
 
-			zfsClient.EXPECT().PoolNames().Return(tc.pools, nil).Times(1)
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return(tc.pools, nil).Times(1)
 			collector, err := NewZFS(config)
 			if err != nil {
 				t.Fatal(err)
@@ -276,7 +356,7 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 						zfsDatasetResults[i] = zfsDatasetProperties
 					}
 					zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
-					zfsDatasets.EXPECT().Properties(tc.propsRequested).Return(zfsDatasetResults, nil).Times(1)
+					zfsDatasets.EXPECT().Properties(gomock.Any(), append(tc.propsRequested, tc.userProps...)).Return(zfsDatasetResults, nil).Times(1)
 					zfsClient.EXPECT().Datasets(pool, kind).Return(zfsDatasets).Times(1)
 				}
 			}
@@ -287,3 +367,367 @@ zfs_dataset_unsupported{name="testpool/test",pool="testpool",type="filesystem"}
 		})
 	}
 }
+
+func TestUserPropertyLabel(t *testing.T) {
+	testCases := map[string]string{
+		`com.example:owner`: `com_example_owner`,
+		`org:cost-centre`:   `org_cost_centre`,
+		`1st:tier`:          `_1st_tier`,
+	}
+	for name, expected := range testCases {
+		if label := userPropertyLabel(name); label != expected {
+			t.Errorf("unexpected label for %s: %s, expected %s", name, label, expected)
+		}
+	}
+}
+
+func TestDatasetUserPropertyErrors(t *testing.T) {
+	for _, userProps := range [][]string{
+		{`owner`},
+		{`com.example:owner`, `com_example:owner`},
+	} {
+		if _, err := newDatasetCollector(zfs.DatasetFilesystem, logger, nil, []string{`used`}, map[string]string{datasetUserPropsOption: strings.Join(userProps, `,`)}); err == nil {
+			t.Errorf("expected error for user properties %v, got nil", userProps)
+		}
+	}
+}
+
+func TestDatasetFilterRoot(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options map[string]string
+		pool    string
+		kind    zfs.DatasetKind
+		root    string
+		depth   int
+		skipped bool
+	}{
+		{
+			name:  `unfiltered`,
+			pool:  `tank`,
+			kind:  zfs.DatasetFilesystem,
+			root:  `tank`,
+			depth: -1,
+		},
+		{
+			name:    `anchored include`,
+			options: map[string]string{datasetIncludeOption: `^tank/backup/`},
+			pool:    `tank`,
+			kind:    zfs.DatasetFilesystem,
+			root:    `tank/backup`,
+			depth:   -1,
+		},
+		{
+			name:    `anchored include partial name`,
+			options: map[string]string{datasetIncludeOption: `^tank/back.*`},
+			pool:    `tank`,
+			kind:    zfs.DatasetFilesystem,
+			root:    `tank`,
+			depth:   -1,
+		},
+		{
+			name:    `anchored include snapshot`,
+			options: map[string]string{datasetIncludeOption: `^tank/home@daily`},
+			pool:    `tank`,
+			kind:    zfs.DatasetSnapshot,
+			root:    `tank/home`,
+			depth:   -1,
+		},
+		{
+			name:    `anchored include bookmark`,
+			options: map[string]string{datasetIncludeOption: `^tank/home#repl`},
+			pool:    `tank`,
+			kind:    zfs.DatasetBookmark,
+			root:    `tank/home`,
+			depth:   -1,
+		},
+		{
+			name:    `anchored include other pool`,
+			options: map[string]string{datasetIncludeOption: `^tank/backup/`},
+			pool:    `rpool`,
+			kind:    zfs.DatasetFilesystem,
+			skipped: true,
+		},
+		{
+			name:    `anchored include pool prefix`,
+			options: map[string]string{datasetIncludeOption: `^tan`},
+			pool:    `tank`,
+			kind:    zfs.DatasetFilesystem,
+			root:    `tank`,
+			depth:   -1,
+		},
+		{
+			name:    `unanchored include`,
+			options: map[string]string{datasetIncludeOption: `tank/backup/`},
+			pool:    `rpool`,
+			kind:    zfs.DatasetFilesystem,
+			root:    `rpool`,
+			depth:   -1,
+		},
+		{
+			name:    `case-insensitive include`,
+			options: map[string]string{datasetIncludeOption: `(?i)^tank/backup/`},
+			pool:    `TANK`,
+			kind:    zfs.DatasetFilesystem,
+			root:    `TANK`,
+			depth:   -1,
+		},
+		{
+			name:    `depth`,
+			options: map[string]string{datasetDepthOption: `1`},
+			pool:    `tank`,
+			kind:    zfs.DatasetFilesystem,
+			root:    `tank`,
+			depth:   1,
+		},
+		{
+			name:    `depth snapshot`,
+			options: map[string]string{datasetDepthOption: `1`},
+			pool:    `tank`,
+			kind:    zfs.DatasetSnapshot,
+			root:    `tank`,
+			depth:   2,
+		},
+		{
+			name:    `depth bookmark`,
+			options: map[string]string{datasetDepthOption: `1`},
+			pool:    `tank`,
+			kind:    zfs.DatasetBookmark,
+			root:    `tank`,
+			depth:   2,
+		},
+		{
+			name:    `depth below root`,
+			options: map[string]string{datasetIncludeOption: `^tank/backup/`, datasetDepthOption: `2`},
+			pool:    `tank`,
+			kind:    zfs.DatasetFilesystem,
+			root:    `tank/backup`,
+			depth:   1,
+		},
+		{
+			name:    `depth above root`,
+			options: map[string]string{datasetIncludeOption: `^tank/backup/daily/`, datasetDepthOption: `1`},
+			pool:    `tank`,
+			kind:    zfs.DatasetFilesystem,
+			skipped: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filter, err := newDatasetFilter(tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, depth, ok := filter.root(tc.pool, tc.kind)
+			if ok == tc.skipped {
+				t.Fatalf("unexpected skipped result for %s: %t", tc.pool, !ok)
+			}
+			if root != tc.root || depth != tc.depth {
+				t.Fatalf("unexpected root: %s (depth %d), expected %s (depth %d)", root, depth, tc.root, tc.depth)
+			}
+		})
+	}
+}
+
+func TestDatasetFilterMetrics(t *testing.T) {
+	const result = `# HELP zfs_dataset_used_bytes The amount of space in bytes consumed by this dataset and all its descendents.
+# TYPE zfs_dataset_used_bytes gauge
+zfs_dataset_used_bytes{name="testpool/backup/host@daily",pool="testpool",type="snapshot"} 1024
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+
+	// The other pool cannot contain included datasets, and is not queried
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`, `otherpool`}, nil).Times(1)
+	zfsDatasetResults := make([]zfs.DatasetProperties, 0, 4)
+	for _, name := range []string{`testpool/backup/host@daily`, `testpool/backup/host/excluded@daily`, `testpool/backup/host/var/log@daily`, `testpool/backup@daily`} {
+		zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+		zfsDatasetProperties.EXPECT().DatasetName().Return(name).MinTimes(1)
+		zfsDatasetProperties.EXPECT().Properties().Return(map[string]string{`used`: `1024`}).AnyTimes()
+		zfsDatasetResults = append(zfsDatasetResults, zfsDatasetProperties)
+	}
+	zfsRootDatasets := mock_zfs.NewMockDatasets(ctrl)
+	zfsRootDatasets.EXPECT().Properties(gomock.Any(), []string{`used`}).Return(zfsDatasetResults, nil).Times(1)
+	zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+	zfsDatasets.EXPECT().Root(`testpool/backup`, 3).Return(zfsRootDatasets).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetSnapshot).Return(zfsDatasets).Times(1)
+
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`dataset-snapshot`: {
+			Name:       "dataset-snapshot",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`used`),
+			Options: map[string]*string{
+				datasetIncludeOption: stringPointer(`^testpool/backup/`),
+				datasetExcludeOption: stringPointer(`/excluded@`),
+				datasetDepthOption:   stringPointer(`3`),
+			},
+			factory: newSnapshotCollector,
+		},
+	}
+
+	if err = callCollector(ctx, collector, []byte(result), []string{`zfs_dataset_used_bytes`}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDatasetFilterErrors(t *testing.T) {
+	for _, options := range []map[string]string{
+		{datasetIncludeOption: `(`},
+		{datasetExcludeOption: `(`},
+		{datasetDepthOption: `-1`},
+		{datasetDepthOption: `deep`},
+	} {
+		if _, err := newDatasetCollector(zfs.DatasetFilesystem, logger, nil, []string{`used`}, options); err == nil {
+			t.Errorf("expected error for options %v, got nil", options)
+		}
+	}
+}
+
+func TestDatasetRootsMetrics(t *testing.T) {
+	const result = `# HELP zfs_dataset_used_bytes The amount of space in bytes consumed by this dataset and all its descendents.
+# TYPE zfs_dataset_used_bytes gauge
+zfs_dataset_used_bytes{name="otherpool/vm",pool="otherpool",type="filesystem"} 2048
+zfs_dataset_used_bytes{name="testpool/vm",pool="testpool",type="filesystem"} 1024
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+	config.DatasetRoots = []string{`testpool/vm/`, `testpool/vm/nested`, `otherpool/vm`}
+
+	// The third pool contains no roots, and is not queried
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`, `otherpool`, `thirdpool`}, nil).Times(1)
+	for pool, used := range map[string]string{`testpool`: `1024`, `otherpool`: `2048`} {
+		zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+		zfsDatasetProperties.EXPECT().DatasetName().Return(pool + `/vm`).Times(2)
+		zfsDatasetProperties.EXPECT().Properties().Return(map[string]string{`used`: used}).Times(1)
+		zfsRootDatasets := mock_zfs.NewMockDatasets(ctrl)
+		zfsRootDatasets.EXPECT().Properties(gomock.Any(), []string{`used`}).Return([]zfs.DatasetProperties{zfsDatasetProperties}, nil).Times(1)
+		zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+		zfsDatasets.EXPECT().Root(pool+`/vm`, -1).Return(zfsRootDatasets).Times(1)
+		zfsClient.EXPECT().Datasets(pool, zfs.DatasetFilesystem).Return(zfsDatasets).Times(1)
+	}
+
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`dataset-filesystem`: {
+			Name:       "dataset-filesystem",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`used`),
+			factory:    newFilesystemCollector,
+		},
+	}
+
+	if err = callCollector(ctx, collector, []byte(result), []string{`zfs_dataset_used_bytes`}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDatasetDerivedMetrics(t *testing.T) {
+	metricNames := []string{`zfs_dataset_quota_utilisation_ratio`, `zfs_dataset_refquota_utilisation_ratio`, `zfs_dataset_reservation_excess_bytes`}
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		propsResults   []datasetResults
+		metricResults  string
+	}{
+		{
+			name:           `quota`,
+			propsRequested: []string{`used`, `quota`},
+			propsResults: []datasetResults{
+				{name: `testpool/limited`, results: map[string]string{`used`: `512`, `quota`: `1024`}},
+				{name: `testpool/unlimited`, results: map[string]string{`used`: `512`, `quota`: `0`}},
+			},
+			metricResults: `# HELP zfs_dataset_quota_utilisation_ratio Ratio of the quota of the dataset consumed by the dataset and all its descendents, only present when a quota is set.
+# TYPE zfs_dataset_quota_utilisation_ratio gauge
+zfs_dataset_quota_utilisation_ratio{name="testpool/limited",pool="testpool",type="filesystem"} 0.5
+`,
+		},
+		{
+			name:           `refquota`,
+			propsRequested: []string{`referenced`, `refquota`},
+			propsResults: []datasetResults{
+				{name: `testpool/limited`, results: map[string]string{`referenced`: `256`, `refquota`: `1024`}},
+				{name: `testpool/unlimited`, results: map[string]string{`referenced`: `256`, `refquota`: `none`}},
+			},
+			metricResults: `# HELP zfs_dataset_refquota_utilisation_ratio Ratio of the referenced quota of the dataset consumed by the dataset, only present when a referenced quota is set.
+# TYPE zfs_dataset_refquota_utilisation_ratio gauge
+zfs_dataset_refquota_utilisation_ratio{name="testpool/limited",pool="testpool",type="filesystem"} 0.25
+`,
+		},
+		{
+			name:           `reservation`,
+			propsRequested: []string{`used`, `reservation`},
+			propsResults: []datasetResults{
+				{name: `testpool/exceeded`, results: map[string]string{`used`: `3072`, `reservation`: `1024`}},
+				{name: `testpool/reserved`, results: map[string]string{`used`: `512`, `reservation`: `1024`}},
+				{name: `testpool/unreserved`, results: map[string]string{`used`: `512`, `reservation`: `0`}},
+			},
+			metricResults: `# HELP zfs_dataset_reservation_excess_bytes Amount of space in bytes consumed by the dataset and all its descendents in excess of the reservation (ie - used minus reservation, or zero when within the reservation), which is not guaranteed, only present when a reservation is set.
+# TYPE zfs_dataset_reservation_excess_bytes gauge
+zfs_dataset_reservation_excess_bytes{name="testpool/exceeded",pool="testpool",type="filesystem"} 2048
+zfs_dataset_reservation_excess_bytes{name="testpool/reserved",pool="testpool",type="filesystem"} 0
+`,
+		},
+		{
+			name:           `missing value`,
+			propsRequested: []string{`quota`},
+			propsResults: []datasetResults{
+				{name: `testpool/limited`, results: map[string]string{`quota`: `1024`}},
+			},
+			metricResults: ``,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsDatasetResults := make([]zfs.DatasetProperties, len(tc.propsResults))
+			for i, propResults := range tc.propsResults {
+				zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+				zfsDatasetProperties.EXPECT().DatasetName().Return(propResults.name).Times(2)
+				zfsDatasetProperties.EXPECT().Properties().Return(propResults.results).Times(1)
+				zfsDatasetResults[i] = zfsDatasetProperties
+			}
+			zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+			zfsDatasets.EXPECT().Properties(gomock.Any(), tc.propsRequested).Return(zfsDatasetResults, nil).Times(1)
+			zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetFilesystem).Return(zfsDatasets).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`dataset-filesystem`: {
+					Name:       "dataset-filesystem",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newFilesystemCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
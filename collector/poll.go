@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errPollStopped is the cause of the cancellation of collector runs upon Stop
+var errPollStopped = errors.New(`polling stopped`)
+
+// Start begins refreshing each enabled collector in the background when polling is configured, such that scrapes are
+// served from the cache. It is a no-op otherwise.
+func (c *ZFS) Start() {
+	if c.pollInterval <= 0 {
+		return
+	}
+	c.pollMu.Lock()
+	defer c.pollMu.Unlock()
+	if c.pollCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	c.pollCancel = cancel
+
+	for name, state := range c.Collectors {
+		if !*state.Enabled {
+			continue
+		}
+		collector, err := c.instance(name, state)
+		if err != nil {
+			c.logger.Error("Error instantiating collector", "collector", name, "err", err)
+			continue
+		}
+		interval, _ := state.interval(c.pollInterval)
+		c.pollWg.Add(1)
+		go func(name string, collector Collector) {
+			defer c.pollWg.Done()
+			c.poll(ctx, name, collector, interval)
+		}(name, collector)
+	}
+}
+
+// Stop ends background polling, cancelling in-flight collector runs and waiting for them to return. Commands killed
+// by the cancellation are not counted as killed commands.
+func (c *ZFS) Stop() {
+	c.pollMu.Lock()
+	defer c.pollMu.Unlock()
+	if c.pollCancel == nil {
+		return
+	}
+	c.pollCancel(errPollStopped)
+	c.pollCancel = nil
+	c.pollWg.Wait()
+}
+
+// poll refreshes the named collector every interval, until ctx is cancelled
+func (c *ZFS) poll(ctx context.Context, name string, collector Collector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.refresh(ctx, name, collector)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh runs the named collector, and replaces its cached metrics upon completion
+func (c *ZFS) refresh(ctx context.Context, name string, collector Collector) {
+	c.statusMu.Lock()
+	status := c.collectorStatus(name)
+	status.running = make(chan struct{})
+	status.started = c.now()
+	c.statusMu.Unlock()
+
+	runCtx, runCancel := c.runContext(ctx)
+	defer runCancel()
+	pools, poolErr := c.getPools(runCtx, c.Pools)
+	// There is no deadline for background runs, so the collector is never considered delayed
+	c.run(runCtx, context.Background(), name, collector, pools, poolErr)
+}
+
+// collectPolled sends the cached metrics for all collectors, as refreshed by background polling. Collectors are
+// considered stale if they have not completed a run within twice their interval.
+func (c *ZFS) collectPolled(ch chan<- prometheus.Metric) {
+	stale := make(map[string]struct{})
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	for name, state := range c.Collectors {
+		if !*state.Enabled {
+			continue
+		}
+		status := c.collectorStatus(name)
+		interval, _ := state.interval(c.pollInterval)
+		if c.now().Sub(status.completed) > 2*interval {
+			stale[name] = struct{}{}
+		}
+		c.sendCached(ch, status.cache, false)
+	}
+	c.sendStatus(ch, make(map[string]struct{}), stale)
+}
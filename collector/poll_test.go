@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestZFSPoll(t *testing.T) {
+	const result = `# HELP zfs_pool_health Health status code for the pool [0: ONLINE, 1: DEGRADED, 2: FAULTED, 3: OFFLINE, 4: UNAVAIL, 5: REMOVED, 6: SUSPENDED].
+# TYPE zfs_pool_health gauge
+zfs_pool_health{pool="testpool"} 0
+# HELP zfs_scrape_collector_stale zfs_exporter: Whether the collector data served by the scrape was served from the cache, as the collector did not complete before the deadline.
+# TYPE zfs_scrape_collector_stale gauge
+zfs_scrape_collector_stale{collector="pool"} 0
+# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded.
+# TYPE zfs_scrape_collector_success gauge
+zfs_scrape_collector_success{collector="pool"} 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	// A single background run serves all scrapes
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+	zfsPoolProperties.EXPECT().Properties().Return(map[string]string{`health`: `ONLINE`}).Times(1)
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	refreshed := make(chan struct{})
+	zfsPool.EXPECT().Properties(gomock.Any(), `health`).DoAndReturn(func(context.Context, ...string) (zfs.PoolProperties, error) {
+		defer close(refreshed)
+		return zfsPoolProperties, nil
+	}).Times(1)
+	zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(1)
+
+	config := defaultConfig(zfsClient)
+	config.DisableMetrics = false
+	config.PollInterval = time.Hour
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`health`),
+			factory:    newPoolCollector,
+		},
+	}
+	collector.Start()
+	defer collector.Stop()
+	<-refreshed
+	waitIdle(collector, `pool`)
+
+	for i := 0; i < 2; i++ {
+		if err = callCollector(ctx, collector, []byte(result), []string{`zfs_pool_health`, `zfs_scrape_collector_stale`, `zfs_scrape_collector_success`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// blockingRunner is a hungRunner that signals each command that blocks
+type blockingRunner struct {
+	hungRunner
+	blocked chan struct{}
+}
+
+func (r blockingRunner) Run(ctx context.Context, stdout io.Writer, name string, arg ...string) error {
+	if name != `zpool` || len(arg) == 0 || arg[0] != `list` {
+		r.blocked <- struct{}{}
+	}
+	return r.hungRunner.Run(ctx, stdout, name, arg...)
+}
+
+func TestZFSPollStop(t *testing.T) {
+	runner := blockingRunner{blocked: make(chan struct{}, 1)}
+	zfsClient, err := zfs.New(zfs.Config{Backend: zfs.BackendText, Runner: runner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := defaultConfig(zfsClient)
+	config.PollInterval = time.Hour
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`health`),
+			factory:    newPoolCollector,
+		},
+	}
+	collector.Start()
+	<-runner.blocked
+	collector.Stop()
+
+	// Commands cancelled by stopping are not counted as killed upon exceeding the timeout
+	collector.statusMu.Lock()
+	killed := collector.collectorStatus(`pool`).killed
+	collector.statusMu.Unlock()
+	if killed != 0 {
+		t.Errorf("expected no killed commands, got %v", killed)
+	}
+}
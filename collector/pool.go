@@ -1,8 +1,11 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/pdf/zfs_exporter/v2/zfs"
@@ -11,9 +14,20 @@ import (
 
 const (
 	defaultPoolProps = `allocated,dedupratio,fragmentation,free,freeing,health,leaked,readonly,size`
+
+	poolFeaturePrefix = `feature@`
 )
 
 var (
+	poolFeatureStateDescName = prometheus.BuildFQName(namespace, subsystemPool, `feature_state`)
+	poolFeatureStateDesc     = prometheus.NewDesc(
+		poolFeatureStateDescName,
+		`State of the pool feature flag, the series with the current state has a value of 1.`,
+		[]string{`pool`, `feature`, `state`},
+		nil,
+	)
+	poolFeatureStates = []string{`disabled`, `enabled`, `active`}
+
 	poolLabels     = []string{`pool`}
 	poolProperties = propertyStore{
 		defaultSubsystem: subsystemPool,
@@ -27,6 +41,70 @@ var (
 				prometheus.GaugeValue,
 				poolLabels...,
 			),
+			`ashift`: newProperty(
+				subsystemPool,
+				`ashift`,
+				`The sector size exponent of the pool, as a power of 2, used when adding vdevs (0: auto-detected).`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`autoexpand`: newProperty(
+				subsystemPool,
+				`autoexpand_enabled`,
+				`Whether the pool expands automatically upon growth of the underlying devices [0: off, 1: on].`,
+				transformBool,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`autotrim`: newProperty(
+				subsystemPool,
+				`autotrim_enabled`,
+				`Whether freed space in the pool is trimmed automatically [0: off, 1: on].`,
+				transformBool,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`bcloneratio`: newProperty(
+				subsystemPool,
+				`block_clone_ratio`,
+				`The ratio of cloned size vs referenced size for cloned blocks in this pool.`,
+				transformMultiplier,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`bclonesaved`: newProperty(
+				subsystemPool,
+				`block_clone_saved_bytes`,
+				`Amount of storage in bytes saved by block cloning in the pool.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`bcloneused`: newProperty(
+				subsystemPool,
+				`block_clone_used_bytes`,
+				`Amount of storage in bytes used by cloned blocks in the pool.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`checkpoint`: newProperty(
+				subsystemPool,
+				`checkpoint_bytes`,
+				`Amount of storage in bytes consumed by the checkpoint of the pool.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`dedup_table_size`: newProperty(
+				subsystemPool,
+				`deduplication_table_size_bytes`,
+				`Size in bytes of the deduplication table of the pool.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
 			`dedupratio`: newProperty(
 				subsystemPool,
 				`deduplication_ratio`,
@@ -43,6 +121,22 @@ var (
 				prometheus.GaugeValue,
 				poolLabels...,
 			),
+			`dedupsaved`: newProperty(
+				subsystemPool,
+				`deduplication_saved_bytes`,
+				`Amount of storage in bytes saved by deduplication in the pool.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`dedupused`: newProperty(
+				subsystemPool,
+				`deduplication_used_bytes`,
+				`Amount of storage in bytes used by deduplicated blocks in the pool.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
 			`expandsize`: newProperty(
 				subsystemPool,
 				`expand_size_bytes`,
@@ -51,6 +145,12 @@ var (
 				prometheus.GaugeValue,
 				poolLabels...,
 			),
+			`failmode`: newInfoProperty(
+				subsystemPool,
+				`failmode_info`,
+				`Behaviour of the pool upon catastrophic failure.`,
+				poolLabels...,
+			),
 			`fragmentation`: newProperty(
 				subsystemPool,
 				`fragmentation_ratio`,
@@ -99,6 +199,12 @@ var (
 				prometheus.GaugeValue,
 				poolLabels...,
 			),
+			`load_guid`: newInfoProperty(
+				subsystemPool,
+				`load_guid_info`,
+				`Unique identifier of the current import of the pool.`,
+				poolLabels...,
+			),
 			`readonly`: newProperty(
 				subsystemPool,
 				`readonly`,
@@ -115,6 +221,14 @@ var (
 				prometheus.GaugeValue,
 				poolLabels...,
 			),
+			`version`: newProperty(
+				subsystemPool,
+				`version`,
+				`The on-disk version of the pool, 0 for pools using feature flags.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
 		},
 	}
 )
@@ -130,23 +244,29 @@ type poolCollector struct {
 }
 
 func (c *poolCollector) describe(ch chan<- *prometheus.Desc) {
+	props := make([]string, 0, len(c.props))
+	features := false
 	for _, k := range c.props {
-		prop, err := poolProperties.find(k)
-		if err != nil {
-			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `pool`, `property`, k, `err`, err)
-			continue
+		if k == propertiesAll || matchesPrefix(k, poolFeaturePrefix) {
+			features = true
+		}
+		if !strings.HasPrefix(k, poolFeaturePrefix) {
+			props = append(props, k)
 		}
-		ch <- prop.desc
 	}
+	if features {
+		ch <- poolFeatureStateDesc
+	}
+	poolProperties.describeSelected(c.log, `pool`, props, ch)
 }
 
-func (c *poolCollector) update(ch chan<- metric, pools []string, excludes regexpCollection) error {
+func (c *poolCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
 	var wg sync.WaitGroup
 	errChan := make(chan error, len(pools))
 	for _, pool := range pools {
 		wg.Add(1)
 		go func(pool string) {
-			if err := c.updatePoolMetrics(ch, pool); err != nil {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
 				errChan <- err
 			}
 			wg.Done()
@@ -162,20 +282,22 @@ func (c *poolCollector) update(ch chan<- metric, pools []string, excludes regexp
 	}
 }
 
-func (c *poolCollector) updatePoolMetrics(ch chan<- metric, pool string) error {
+func (c *poolCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
 	p := c.client.Pool(pool)
-	props, err := p.Properties(c.props...)
+	props, err := p.Properties(ctx, c.props...)
 	if err != nil {
 		return err
 	}
 
 	labelValues := []string{pool}
 	for k, v := range props.Properties() {
-		prop, err := poolProperties.find(k)
-		if err != nil {
-			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `pool`, `property`, k, `err`, err)
+		if feature, ok := strings.CutPrefix(k, poolFeaturePrefix); ok {
+			if err = pushFeatureState(ch, pool, feature, v); err != nil {
+				return err
+			}
+			continue
 		}
-		if err = prop.push(ch, v, labelValues...); err != nil {
+		if err = poolProperties.pushSelected(c.log, `pool`, c.props, ch, k, v, labelValues...); err != nil {
 			return err
 		}
 	}
@@ -183,6 +305,52 @@ func (c *poolCollector) updatePoolMetrics(ch chan<- metric, pool string) error {
 	return nil
 }
 
-func newPoolCollector(l *slog.Logger, c zfs.Client, props []string) (Collector, error) {
-	return &poolCollector{log: l, client: c, props: props}, nil
+// matchesPrefix reports whether the property, or any name matched by the property pattern, may start with the prefix.
+// Character classes are assumed to match.
+func matchesPrefix(prop, prefix string) bool {
+	for i := 0; i < len(prop); i++ {
+		if len(prefix) == 0 {
+			return true
+		}
+		switch prop[i] {
+		case '*', '[':
+			return true
+		case '?':
+		case '\\':
+			if i+1 < len(prop) {
+				i++
+			}
+			fallthrough
+		default:
+			if prop[i] != prefix[0] {
+				return false
+			}
+		}
+		prefix = prefix[1:]
+	}
+
+	return len(prefix) == 0
+}
+
+// pushFeatureState sends the state-set metrics for the pool feature flag
+func pushFeatureState(ch chan<- metric, pool, feature, value string) error {
+	if !slices.Contains(poolFeatureStates, value) {
+		return fmt.Errorf("unknown state for pool feature %s: %s", feature, value)
+	}
+	for _, state := range poolFeatureStates {
+		var v float64
+		if state == value {
+			v = 1
+		}
+		labelValues := []string{pool, feature, state}
+		ch <- metric{
+			name:       expandMetricName(poolFeatureStateDescName, labelValues...),
+			prometheus: prometheus.MustNewConstMetric(poolFeatureStateDesc, prometheus.GaugeValue, v, labelValues...),
+		}
+	}
+	return nil
+}
+
+func newPoolCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return &poolCollector{log: l, client: c, props: expandProperties(props, poolProperties.names(), true)}, nil
 }
@@ -0,0 +1,288 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultPoolIOProps = `arc_read_bytes,arc_read_count,arc_write_bytes,arc_write_count,nread,nwritten,reads,rlentime,rtime,wlentime,writes,wtime`
+)
+
+var (
+	poolIOProperties = propertyStore{
+		defaultSubsystem: subsystemPoolIO,
+		defaultLabels:    poolLabels,
+		store: map[string]property{
+			`arc_read_bytes`: newProperty(
+				subsystemPoolIO,
+				`arc_read_bytes_total`,
+				`Amount of data in bytes read through the ARC for the pool.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`arc_read_count`: newProperty(
+				subsystemPoolIO,
+				`arc_reads_total`,
+				`Number of reads through the ARC for the pool.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`arc_write_bytes`: newProperty(
+				subsystemPoolIO,
+				`arc_written_bytes_total`,
+				`Amount of data in bytes written through the ARC for the pool.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`arc_write_count`: newProperty(
+				subsystemPoolIO,
+				`arc_writes_total`,
+				`Number of writes through the ARC for the pool.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`autotrim_bytes_written`: newProperty(
+				subsystemPoolIO,
+				`autotrim_written_bytes_total`,
+				`Amount of space in bytes trimmed by automatic TRIM.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`autotrim_extents_written`: newProperty(
+				subsystemPoolIO,
+				`autotrim_extents_written_total`,
+				`Number of extents trimmed by automatic TRIM.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`direct_read_bytes`: newProperty(
+				subsystemPoolIO,
+				`direct_read_bytes_total`,
+				`Amount of data in bytes read using Direct I/O, bypassing the ARC.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`direct_read_count`: newProperty(
+				subsystemPoolIO,
+				`direct_reads_total`,
+				`Number of reads using Direct I/O, bypassing the ARC.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`direct_write_bytes`: newProperty(
+				subsystemPoolIO,
+				`direct_written_bytes_total`,
+				`Amount of data in bytes written using Direct I/O, bypassing the ARC.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`direct_write_count`: newProperty(
+				subsystemPoolIO,
+				`direct_writes_total`,
+				`Number of writes using Direct I/O, bypassing the ARC.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`nread`: newProperty(
+				subsystemPoolIO,
+				`read_bytes_total`,
+				`Amount of data in bytes read from the pool.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`nwritten`: newProperty(
+				subsystemPoolIO,
+				`written_bytes_total`,
+				`Amount of data in bytes written to the pool.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`rcnt`: newProperty(
+				subsystemPoolIO,
+				`run_queue_length`,
+				`Number of operations in the run queue of the pool.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`reads`: newProperty(
+				subsystemPoolIO,
+				`reads_total`,
+				`Number of read operations completed by the pool.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`rlentime`: newProperty(
+				subsystemPoolIO,
+				`run_queue_length_seconds_total`,
+				`Cumulative run queue length multiplied by the time in seconds at that length.`,
+				transformNanoseconds,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`rtime`: newProperty(
+				subsystemPoolIO,
+				`run_time_seconds_total`,
+				`Cumulative time in seconds that the run queue of the pool was not empty.`,
+				transformNanoseconds,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`trim_bytes_failed`: newProperty(
+				subsystemPoolIO,
+				`trim_failed_bytes_total`,
+				`Amount of space in bytes that manual TRIM failed to trim.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`trim_bytes_skipped`: newProperty(
+				subsystemPoolIO,
+				`trim_skipped_bytes_total`,
+				`Amount of space in bytes skipped by manual TRIM.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`trim_bytes_written`: newProperty(
+				subsystemPoolIO,
+				`trim_written_bytes_total`,
+				`Amount of space in bytes trimmed by manual TRIM.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`trim_extents_written`: newProperty(
+				subsystemPoolIO,
+				`trim_extents_written_total`,
+				`Number of extents trimmed by manual TRIM.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`wcnt`: newProperty(
+				subsystemPoolIO,
+				`wait_queue_length`,
+				`Number of operations in the wait queue of the pool.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				poolLabels...,
+			),
+			`wlentime`: newProperty(
+				subsystemPoolIO,
+				`wait_queue_length_seconds_total`,
+				`Cumulative wait queue length multiplied by the time in seconds at that length.`,
+				transformNanoseconds,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`writes`: newProperty(
+				subsystemPoolIO,
+				`writes_total`,
+				`Number of write operations completed by the pool.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+			`wtime`: newProperty(
+				subsystemPoolIO,
+				`wait_time_seconds_total`,
+				`Cumulative time in seconds that the wait queue of the pool was not empty.`,
+				transformNanoseconds,
+				prometheus.CounterValue,
+				poolLabels...,
+			),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`pool-io`, defaultDisabled, defaultPoolIOProps, newPoolIOCollector)
+}
+
+type poolIOCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *poolIOCollector) describe(ch chan<- *prometheus.Desc) {
+	for _, k := range c.props {
+		prop, err := poolIOProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `pool-io`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *poolIOCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *poolIOCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
+	stats, err := c.client.Pool(pool).IOStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	labelValues := []string{pool}
+	for _, k := range c.props {
+		v, ok := stats[k]
+		if !ok {
+			// Available statistics vary between ZFS versions.
+			c.log.Debug(`Statistic unavailable`, `collector`, `pool-io`, `pool`, pool, `property`, k)
+			continue
+		}
+		prop, err := poolIOProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `pool-io`, `property`, k, `err`, err)
+		}
+		if err = prop.push(ch, v, labelValues...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newPoolIOCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return &poolIOCollector{log: l, client: c, props: expandProperties(props, poolIOProperties.names(), false)}, nil
+}
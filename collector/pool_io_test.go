@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPoolIOMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		pools          []string
+		explicitPools  []string
+		propsRequested []string
+		metricNames    []string
+		statsResults   map[string]map[string]string
+		metricResults  string
+	}{
+		{
+			name:           `io kstat`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`nread`, `nwritten`, `reads`, `writes`, `wtime`, `wlentime`, `rtime`, `rlentime`, `wcnt`, `rcnt`},
+			metricNames:    []string{`zfs_pool_io_read_bytes_total`, `zfs_pool_io_written_bytes_total`, `zfs_pool_io_reads_total`, `zfs_pool_io_writes_total`, `zfs_pool_io_wait_time_seconds_total`, `zfs_pool_io_wait_queue_length_seconds_total`, `zfs_pool_io_run_time_seconds_total`, `zfs_pool_io_run_queue_length_seconds_total`, `zfs_pool_io_wait_queue_length`, `zfs_pool_io_run_queue_length`},
+			statsResults: map[string]map[string]string{
+				`testpool`: {
+					`nread`:    `1884160`,
+					`nwritten`: `3206144`,
+					`reads`:    `37`,
+					`writes`:   `169`,
+					`wtime`:    `9283432`,
+					`wlentime`: `55082014`,
+					`wupdate`:  `2222871876093`,
+					`rtime`:    `13434230`,
+					`rlentime`: `66802412`,
+					`rupdate`:  `2222871902283`,
+					`wcnt`:     `0`,
+					`rcnt`:     `1`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_io_read_bytes_total Amount of data in bytes read from the pool.
+# TYPE zfs_pool_io_read_bytes_total counter
+zfs_pool_io_read_bytes_total{pool="testpool"} 1.88416e+06
+# HELP zfs_pool_io_reads_total Number of read operations completed by the pool.
+# TYPE zfs_pool_io_reads_total counter
+zfs_pool_io_reads_total{pool="testpool"} 37
+# HELP zfs_pool_io_run_queue_length Number of operations in the run queue of the pool.
+# TYPE zfs_pool_io_run_queue_length gauge
+zfs_pool_io_run_queue_length{pool="testpool"} 1
+# HELP zfs_pool_io_run_queue_length_seconds_total Cumulative run queue length multiplied by the time in seconds at that length.
+# TYPE zfs_pool_io_run_queue_length_seconds_total counter
+zfs_pool_io_run_queue_length_seconds_total{pool="testpool"} 0.066802412
+# HELP zfs_pool_io_run_time_seconds_total Cumulative time in seconds that the run queue of the pool was not empty.
+# TYPE zfs_pool_io_run_time_seconds_total counter
+zfs_pool_io_run_time_seconds_total{pool="testpool"} 0.01343423
+# HELP zfs_pool_io_wait_queue_length Number of operations in the wait queue of the pool.
+# TYPE zfs_pool_io_wait_queue_length gauge
+zfs_pool_io_wait_queue_length{pool="testpool"} 0
+# HELP zfs_pool_io_wait_queue_length_seconds_total Cumulative wait queue length multiplied by the time in seconds at that length.
+# TYPE zfs_pool_io_wait_queue_length_seconds_total counter
+zfs_pool_io_wait_queue_length_seconds_total{pool="testpool"} 0.055082014
+# HELP zfs_pool_io_wait_time_seconds_total Cumulative time in seconds that the wait queue of the pool was not empty.
+# TYPE zfs_pool_io_wait_time_seconds_total counter
+zfs_pool_io_wait_time_seconds_total{pool="testpool"} 0.009283432
+# HELP zfs_pool_io_writes_total Number of write operations completed by the pool.
+# TYPE zfs_pool_io_writes_total counter
+zfs_pool_io_writes_total{pool="testpool"} 169
+# HELP zfs_pool_io_written_bytes_total Amount of data in bytes written to the pool.
+# TYPE zfs_pool_io_written_bytes_total counter
+zfs_pool_io_written_bytes_total{pool="testpool"} 3.206144e+06
+`,
+		},
+		{
+			name:           `iostats kstat with explicit pools`,
+			pools:          []string{`testpool1`, `testpool2`},
+			explicitPools:  []string{`testpool2`},
+			propsRequested: []string{`arc_read_bytes`, `arc_read_count`, `nread`},
+			metricNames:    []string{`zfs_pool_io_arc_read_bytes_total`, `zfs_pool_io_arc_reads_total`, `zfs_pool_io_read_bytes_total`},
+			statsResults: map[string]map[string]string{
+				`testpool2`: {
+					`arc_read_bytes`: `4194304`,
+					`arc_read_count`: `1024`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_io_arc_read_bytes_total Amount of data in bytes read through the ARC for the pool.
+# TYPE zfs_pool_io_arc_read_bytes_total counter
+zfs_pool_io_arc_read_bytes_total{pool="testpool2"} 4.194304e+06
+# HELP zfs_pool_io_arc_reads_total Number of reads through the ARC for the pool.
+# TYPE zfs_pool_io_arc_reads_total counter
+zfs_pool_io_arc_reads_total{pool="testpool2"} 1024
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+			if tc.explicitPools != nil {
+				config.Pools = tc.explicitPools
+			}
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return(tc.pools, nil).Times(1)
+			for pool, stats := range tc.statsResults {
+				zfsPool := mock_zfs.NewMockPool(ctrl)
+				zfsPool.EXPECT().IOStats(gomock.Any()).Return(stats, nil).Times(1)
+				zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
+			}
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`pool-io`: {
+					Name:       "pool-io",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newPoolIOCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
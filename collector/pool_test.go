@@ -164,6 +164,182 @@ zfs_pool_health{pool="suspendedpool"} 6
 			metricResults: `# HELP zfs_pool_unsupported !!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/pdf/zfs_exporter/issues to have this property supported !!!
 # TYPE zfs_pool_unsupported gauge
 zfs_pool_unsupported{pool="testpool"} 1024
+`,
+		},
+		{
+			name:           `modern properties`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`ashift`, `autoexpand`, `autotrim`, `bcloneratio`, `bclonesaved`, `bcloneused`, `checkpoint`, `dedup_table_size`, `dedupsaved`, `dedupused`, `failmode`, `load_guid`, `version`},
+			metricNames:    []string{`zfs_pool_ashift`, `zfs_pool_autoexpand_enabled`, `zfs_pool_autotrim_enabled`, `zfs_pool_block_clone_ratio`, `zfs_pool_block_clone_saved_bytes`, `zfs_pool_block_clone_used_bytes`, `zfs_pool_checkpoint_bytes`, `zfs_pool_deduplication_table_size_bytes`, `zfs_pool_deduplication_saved_bytes`, `zfs_pool_deduplication_used_bytes`, `zfs_pool_failmode_info`, `zfs_pool_load_guid_info`, `zfs_pool_version`},
+			propsResults: map[string]map[string]string{
+				`testpool`: {
+					`ashift`:           `12`,
+					`autoexpand`:       `off`,
+					`autotrim`:         `on`,
+					`bcloneratio`:      `4.00`,
+					`bclonesaved`:      `3072`,
+					`bcloneused`:       `1024`,
+					`checkpoint`:       `-`,
+					`dedup_table_size`: `4096`,
+					`dedupsaved`:       `2048`,
+					`dedupused`:        `512`,
+					`failmode`:         `wait`,
+					`load_guid`:        `16851426541837624117`,
+					`version`:          `-`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_ashift The sector size exponent of the pool, as a power of 2, used when adding vdevs (0: auto-detected).
+# TYPE zfs_pool_ashift gauge
+zfs_pool_ashift{pool="testpool"} 12
+# HELP zfs_pool_autoexpand_enabled Whether the pool expands automatically upon growth of the underlying devices [0: off, 1: on].
+# TYPE zfs_pool_autoexpand_enabled gauge
+zfs_pool_autoexpand_enabled{pool="testpool"} 0
+# HELP zfs_pool_autotrim_enabled Whether freed space in the pool is trimmed automatically [0: off, 1: on].
+# TYPE zfs_pool_autotrim_enabled gauge
+zfs_pool_autotrim_enabled{pool="testpool"} 1
+# HELP zfs_pool_block_clone_ratio The ratio of cloned size vs referenced size for cloned blocks in this pool.
+# TYPE zfs_pool_block_clone_ratio gauge
+zfs_pool_block_clone_ratio{pool="testpool"} 0.25
+# HELP zfs_pool_block_clone_saved_bytes Amount of storage in bytes saved by block cloning in the pool.
+# TYPE zfs_pool_block_clone_saved_bytes gauge
+zfs_pool_block_clone_saved_bytes{pool="testpool"} 3072
+# HELP zfs_pool_block_clone_used_bytes Amount of storage in bytes used by cloned blocks in the pool.
+# TYPE zfs_pool_block_clone_used_bytes gauge
+zfs_pool_block_clone_used_bytes{pool="testpool"} 1024
+# HELP zfs_pool_checkpoint_bytes Amount of storage in bytes consumed by the checkpoint of the pool.
+# TYPE zfs_pool_checkpoint_bytes gauge
+zfs_pool_checkpoint_bytes{pool="testpool"} 0
+# HELP zfs_pool_deduplication_saved_bytes Amount of storage in bytes saved by deduplication in the pool.
+# TYPE zfs_pool_deduplication_saved_bytes gauge
+zfs_pool_deduplication_saved_bytes{pool="testpool"} 2048
+# HELP zfs_pool_deduplication_table_size_bytes Size in bytes of the deduplication table of the pool.
+# TYPE zfs_pool_deduplication_table_size_bytes gauge
+zfs_pool_deduplication_table_size_bytes{pool="testpool"} 4096
+# HELP zfs_pool_deduplication_used_bytes Amount of storage in bytes used by deduplicated blocks in the pool.
+# TYPE zfs_pool_deduplication_used_bytes gauge
+zfs_pool_deduplication_used_bytes{pool="testpool"} 512
+# HELP zfs_pool_failmode_info Behaviour of the pool upon catastrophic failure.
+# TYPE zfs_pool_failmode_info gauge
+zfs_pool_failmode_info{pool="testpool",value="wait"} 1
+# HELP zfs_pool_load_guid_info Unique identifier of the current import of the pool.
+# TYPE zfs_pool_load_guid_info gauge
+zfs_pool_load_guid_info{pool="testpool",value="16851426541837624117"} 1
+# HELP zfs_pool_version The on-disk version of the pool, 0 for pools using feature flags.
+# TYPE zfs_pool_version gauge
+zfs_pool_version{pool="testpool"} 0
+`,
+		},
+		{
+			name:           `feature states`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`feature@block_cloning`, `feature@draid`, `feature@raidz_expansion`},
+			metricNames:    []string{`zfs_pool_feature_state`},
+			propsResults: map[string]map[string]string{
+				`testpool`: {
+					`feature@block_cloning`:   `active`,
+					`feature@draid`:           `enabled`,
+					`feature@raidz_expansion`: `disabled`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_feature_state State of the pool feature flag, the series with the current state has a value of 1.
+# TYPE zfs_pool_feature_state gauge
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="active"} 1
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="enabled"} 0
+zfs_pool_feature_state{feature="draid",pool="testpool",state="active"} 0
+zfs_pool_feature_state{feature="draid",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="draid",pool="testpool",state="enabled"} 1
+zfs_pool_feature_state{feature="raidz_expansion",pool="testpool",state="active"} 0
+zfs_pool_feature_state{feature="raidz_expansion",pool="testpool",state="disabled"} 1
+zfs_pool_feature_state{feature="raidz_expansion",pool="testpool",state="enabled"} 0
+`,
+		},
+		{
+			name:           `all feature states`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`health`, `feature@*`},
+			metricNames:    []string{`zfs_pool_health`, `zfs_pool_feature_state`},
+			propsResults: map[string]map[string]string{
+				`testpool`: {
+					`health`:                `ONLINE`,
+					`feature@async_destroy`: `enabled`,
+					`feature@block_cloning`: `active`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_feature_state State of the pool feature flag, the series with the current state has a value of 1.
+# TYPE zfs_pool_feature_state gauge
+zfs_pool_feature_state{feature="async_destroy",pool="testpool",state="active"} 0
+zfs_pool_feature_state{feature="async_destroy",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="async_destroy",pool="testpool",state="enabled"} 1
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="active"} 1
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="enabled"} 0
+# HELP zfs_pool_health Health status code for the pool [0: ONLINE, 1: DEGRADED, 2: FAULTED, 3: OFFLINE, 4: UNAVAIL, 5: REMOVED, 6: SUSPENDED].
+# TYPE zfs_pool_health gauge
+zfs_pool_health{pool="testpool"} 0
+`,
+		},
+		{
+			name:           `generic pattern`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`f*`},
+			metricNames:    []string{`zfs_pool_feature_state`, `zfs_pool_failmode_info`},
+			propsResults: map[string]map[string]string{
+				`testpool`: {
+					`failmode`:              `wait`,
+					`feature@block_cloning`: `active`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_failmode_info Behaviour of the pool upon catastrophic failure.
+# TYPE zfs_pool_failmode_info gauge
+zfs_pool_failmode_info{pool="testpool",value="wait"} 1
+# HELP zfs_pool_feature_state State of the pool feature flag, the series with the current state has a value of 1.
+# TYPE zfs_pool_feature_state gauge
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="active"} 1
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="enabled"} 0
+`,
+		},
+		{
+			name:           `all properties`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`all`},
+			metricNames:    []string{`zfs_pool_allocated_bytes`, `zfs_pool_feature_state`, `zfs_pool_failmode_info`},
+			propsResults: map[string]map[string]string{
+				`testpool`: {
+					`allocated`:             `1024`,
+					`comment`:               `primary storage`,
+					`failmode`:              `wait`,
+					`feature@block_cloning`: `active`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_allocated_bytes Amount of storage in bytes used within the pool.
+# TYPE zfs_pool_allocated_bytes gauge
+zfs_pool_allocated_bytes{pool="testpool"} 1024
+# HELP zfs_pool_failmode_info Behaviour of the pool upon catastrophic failure.
+# TYPE zfs_pool_failmode_info gauge
+zfs_pool_failmode_info{pool="testpool",value="wait"} 1
+# HELP zfs_pool_feature_state State of the pool feature flag, the series with the current state has a value of 1.
+# TYPE zfs_pool_feature_state gauge
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="active"} 1
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="disabled"} 0
+zfs_pool_feature_state{feature="block_cloning",pool="testpool",state="enabled"} 0
+`,
+		},
+		{
+			name:           `unsupported properties by pattern`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`gu*`},
+			metricNames:    []string{`zfs_pool_guid`, `zfs_pool_guidance`},
+			propsResults: map[string]map[string]string{
+				`testpool`: {
+					`guid`:     `1234`,
+					`guidance`: `none given`,
+				},
+			},
+			metricResults: `# HELP zfs_pool_guid !!! This property is unsupported, results are likely to be undesirable, please file an issue at https://github.com/pdf/zfs_exporter/issues to have this property supported !!!
+# TYPE zfs_pool_guid gauge
+zfs_pool_guid{pool="testpool"} 1234
 `,
 		},
 		{
@@ -198,7 +374,7 @@ zfs_pool_deduplication_ratio{pool="testpool"} 0.4
 				config.Pools = tc.explicitPools
 			}
 
-			zfsClient.EXPECT().PoolNames().Return(tc.pools, nil).Times(1)
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return(tc.pools, nil).Times(1)
 			for _, pool := range tc.pools {
 				if tc.explicitPools != nil {
 					wanted := false
@@ -215,7 +391,7 @@ zfs_pool_deduplication_ratio{pool="testpool"} 0.4
 				zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
 				zfsPoolProperties.EXPECT().Properties().Return(tc.propsResults[pool]).Times(1)
 				zfsPool := mock_zfs.NewMockPool(ctrl)
-				zfsPool.EXPECT().Properties(tc.propsRequested).Return(zfsPoolProperties, nil).Times(1)
+				zfsPool.EXPECT().Properties(gomock.Any(), tc.propsRequested).Return(zfsPoolProperties, nil).Times(1)
 				zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
 			}
 
@@ -238,3 +414,26 @@ zfs_pool_deduplication_ratio{pool="testpool"} 0.4
 		})
 	}
 }
+
+func TestMatchesPrefix(t *testing.T) {
+	testCases := []struct {
+		prop     string
+		expected bool
+	}{
+		{prop: `feature@async_destroy`, expected: true},
+		{prop: `feature@*`, expected: true},
+		{prop: `*`, expected: true},
+		{prop: `f*`, expected: true},
+		{prop: `fea?ure@*`, expected: true},
+		{prop: `[ef]eature@*`, expected: true},
+		{prop: `feature`, expected: false},
+		{prop: `fa*`, expected: false},
+		{prop: `health`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if result := matchesPrefix(tc.prop, poolFeaturePrefix); result != tc.expected {
+			t.Errorf("unexpected result for %s: %v, expected %v", tc.prop, result, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultScanProps = `end,errors,issued,progress,remaining,repaired,scanned,start,to_process`
+)
+
+var (
+	scanLabels     = []string{`pool`}
+	scanInfoLabels = []string{`pool`, `function`, `state`}
+	scanInfo       = newProperty(
+		subsystemScan,
+		`info`,
+		`Information about the most recent scrub or resilver of the pool.`,
+		transformNumeric,
+		prometheus.GaugeValue,
+		scanInfoLabels...,
+	)
+	scanProperties = propertyStore{
+		defaultSubsystem: subsystemScan,
+		defaultLabels:    scanLabels,
+		store: map[string]property{
+			`end`: newProperty(
+				subsystemScan,
+				`end_timestamp_seconds`,
+				`The unix timestamp when the most recent scan finished or was canceled.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+			`errors`: newProperty(
+				subsystemScan,
+				`errors`,
+				`Number of errors encountered by the most recent finished scan.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+			`issued`: newProperty(
+				subsystemScan,
+				`issued_bytes`,
+				`Amount of data in bytes issued for verification by the current scan.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+			`progress`: newProperty(
+				subsystemScan,
+				`progress_ratio`,
+				`Ratio of the most recent scan that has completed.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+			`remaining`: newProperty(
+				subsystemScan,
+				`remaining_seconds`,
+				`Estimated number of seconds until the current scan completes.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+			`repaired`: newProperty(
+				subsystemScan,
+				`repaired_bytes`,
+				`Amount of data in bytes repaired by a scrub, or resilvered by a resilver.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+			`scanned`: newProperty(
+				subsystemScan,
+				`scanned_bytes`,
+				`Amount of data in bytes scanned by the current scan.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+			`start`: newProperty(
+				subsystemScan,
+				`start_timestamp_seconds`,
+				`The unix timestamp when the most recent scan started.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+			`to_process`: newProperty(
+				subsystemScan,
+				`to_process_bytes`,
+				`Total amount of data in bytes that the current scan must process.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				scanLabels...,
+			),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`scan`, defaultDisabled, defaultScanProps, newScanCollector)
+}
+
+type scanCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *scanCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- scanInfo.desc
+	for _, k := range c.props {
+		prop, err := scanProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `scan`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *scanCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *scanCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
+	status, err := c.client.Pool(pool).ScanStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status.State == zfs.ScanStateUnknown {
+		c.log.Debug(`Unrecognised scan status`, `collector`, `scan`, `pool`, pool, `status`, status.Unrecognised)
+	}
+
+	labelValues := []string{pool}
+	if err = scanInfo.push(ch, `1`, pool, string(status.Function), string(status.State)); err != nil {
+		return err
+	}
+	values := scanValues(status)
+	for _, k := range c.props {
+		v, ok := values[k]
+		if !ok {
+			continue
+		}
+		prop, err := scanProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `scan`, `property`, k, `err`, err)
+			continue
+		}
+		if err = prop.push(ch, v, labelValues...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanValues maps the fields of the status that are known for the current scan state to their property names.
+func scanValues(status zfs.ScanStatus) map[string]string {
+	values := make(map[string]string)
+	if status.Function == zfs.ScanFunctionNone {
+		return values
+	}
+	if !status.Start.IsZero() {
+		values[`start`] = strconv.FormatInt(status.Start.Unix(), 10)
+	}
+	if !status.End.IsZero() {
+		values[`end`] = strconv.FormatInt(status.End.Unix(), 10)
+	}
+	switch status.State {
+	case zfs.ScanStateFinished:
+		values[`errors`] = strconv.FormatUint(status.Errors, 10)
+		values[`progress`] = strconv.FormatFloat(status.Progress, 'f', -1, 64)
+		values[`repaired`] = strconv.FormatUint(status.Repaired, 10)
+	case zfs.ScanStateScanning, zfs.ScanStatePaused:
+		values[`issued`] = strconv.FormatUint(status.Issued, 10)
+		values[`progress`] = strconv.FormatFloat(status.Progress, 'f', -1, 64)
+		values[`repaired`] = strconv.FormatUint(status.Repaired, 10)
+		values[`scanned`] = strconv.FormatUint(status.Scanned, 10)
+		values[`to_process`] = strconv.FormatUint(status.ToProcess, 10)
+		if status.Remaining > 0 {
+			values[`remaining`] = strconv.FormatFloat(status.Remaining.Seconds(), 'f', -1, 64)
+		}
+	}
+
+	return values
+}
+
+func newScanCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return &scanCollector{log: l, client: c, props: expandProperties(props, scanProperties.names(), false)}, nil
+}
@@ -0,0 +1,171 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestScanMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		pools          []string
+		propsRequested []string
+		metricNames    []string
+		scanResults    map[string]zfs.ScanStatus
+		metricResults  string
+	}{
+		{
+			name:           `scrub finished`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`end`, `errors`, `issued`, `progress`, `remaining`, `repaired`, `scanned`, `start`, `to_process`},
+			metricNames:    []string{`zfs_scan_info`, `zfs_scan_end_timestamp_seconds`, `zfs_scan_errors`, `zfs_scan_issued_bytes`, `zfs_scan_progress_ratio`, `zfs_scan_remaining_seconds`, `zfs_scan_repaired_bytes`, `zfs_scan_scanned_bytes`, `zfs_scan_start_timestamp_seconds`, `zfs_scan_to_process_bytes`},
+			scanResults: map[string]zfs.ScanStatus{
+				`testpool`: {
+					Function: zfs.ScanFunctionScrub,
+					State:    zfs.ScanStateFinished,
+					Start:    time.Unix(1728778441, 0),
+					End:      time.Unix(1728779064, 0),
+					Repaired: 1024,
+					Errors:   2,
+					Progress: 1,
+				},
+			},
+			metricResults: `# HELP zfs_scan_end_timestamp_seconds The unix timestamp when the most recent scan finished or was canceled.
+# TYPE zfs_scan_end_timestamp_seconds gauge
+zfs_scan_end_timestamp_seconds{pool="testpool"} 1.728779064e+09
+# HELP zfs_scan_errors Number of errors encountered by the most recent finished scan.
+# TYPE zfs_scan_errors gauge
+zfs_scan_errors{pool="testpool"} 2
+# HELP zfs_scan_info Information about the most recent scrub or resilver of the pool.
+# TYPE zfs_scan_info gauge
+zfs_scan_info{function="scrub",pool="testpool",state="finished"} 1
+# HELP zfs_scan_progress_ratio Ratio of the most recent scan that has completed.
+# TYPE zfs_scan_progress_ratio gauge
+zfs_scan_progress_ratio{pool="testpool"} 1
+# HELP zfs_scan_repaired_bytes Amount of data in bytes repaired by a scrub, or resilvered by a resilver.
+# TYPE zfs_scan_repaired_bytes gauge
+zfs_scan_repaired_bytes{pool="testpool"} 1024
+# HELP zfs_scan_start_timestamp_seconds The unix timestamp when the most recent scan started.
+# TYPE zfs_scan_start_timestamp_seconds gauge
+zfs_scan_start_timestamp_seconds{pool="testpool"} 1.728778441e+09
+`,
+		},
+		{
+			name:           `resilver in progress`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`end`, `errors`, `issued`, `progress`, `remaining`, `repaired`, `scanned`, `start`, `to_process`},
+			metricNames:    []string{`zfs_scan_info`, `zfs_scan_end_timestamp_seconds`, `zfs_scan_errors`, `zfs_scan_issued_bytes`, `zfs_scan_progress_ratio`, `zfs_scan_remaining_seconds`, `zfs_scan_repaired_bytes`, `zfs_scan_scanned_bytes`, `zfs_scan_start_timestamp_seconds`, `zfs_scan_to_process_bytes`},
+			scanResults: map[string]zfs.ScanStatus{
+				`testpool`: {
+					Function:  zfs.ScanFunctionResilver,
+					State:     zfs.ScanStateScanning,
+					Start:     time.Unix(1728778441, 0),
+					ToProcess: 4096,
+					Scanned:   3072,
+					Issued:    2048,
+					Repaired:  1024,
+					Progress:  0.5,
+					Remaining: 30 * time.Minute,
+				},
+			},
+			metricResults: `# HELP zfs_scan_info Information about the most recent scrub or resilver of the pool.
+# TYPE zfs_scan_info gauge
+zfs_scan_info{function="resilver",pool="testpool",state="scanning"} 1
+# HELP zfs_scan_issued_bytes Amount of data in bytes issued for verification by the current scan.
+# TYPE zfs_scan_issued_bytes gauge
+zfs_scan_issued_bytes{pool="testpool"} 2048
+# HELP zfs_scan_progress_ratio Ratio of the most recent scan that has completed.
+# TYPE zfs_scan_progress_ratio gauge
+zfs_scan_progress_ratio{pool="testpool"} 0.5
+# HELP zfs_scan_remaining_seconds Estimated number of seconds until the current scan completes.
+# TYPE zfs_scan_remaining_seconds gauge
+zfs_scan_remaining_seconds{pool="testpool"} 1800
+# HELP zfs_scan_repaired_bytes Amount of data in bytes repaired by a scrub, or resilvered by a resilver.
+# TYPE zfs_scan_repaired_bytes gauge
+zfs_scan_repaired_bytes{pool="testpool"} 1024
+# HELP zfs_scan_scanned_bytes Amount of data in bytes scanned by the current scan.
+# TYPE zfs_scan_scanned_bytes gauge
+zfs_scan_scanned_bytes{pool="testpool"} 3072
+# HELP zfs_scan_start_timestamp_seconds The unix timestamp when the most recent scan started.
+# TYPE zfs_scan_start_timestamp_seconds gauge
+zfs_scan_start_timestamp_seconds{pool="testpool"} 1.728778441e+09
+# HELP zfs_scan_to_process_bytes Total amount of data in bytes that the current scan must process.
+# TYPE zfs_scan_to_process_bytes gauge
+zfs_scan_to_process_bytes{pool="testpool"} 4096
+`,
+		},
+		{
+			name:           `never scanned`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`end`, `start`},
+			metricNames:    []string{`zfs_scan_info`, `zfs_scan_end_timestamp_seconds`, `zfs_scan_start_timestamp_seconds`},
+			scanResults: map[string]zfs.ScanStatus{
+				`testpool`: {
+					Function: zfs.ScanFunctionNone,
+					State:    zfs.ScanStateNone,
+				},
+			},
+			metricResults: `# HELP zfs_scan_info Information about the most recent scrub or resilver of the pool.
+# TYPE zfs_scan_info gauge
+zfs_scan_info{function="none",pool="testpool",state="none"} 1
+`,
+		},
+		{
+			name:           `unrecognised`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`end`, `progress`, `start`},
+			metricNames:    []string{`zfs_scan_info`, `zfs_scan_end_timestamp_seconds`, `zfs_scan_progress_ratio`, `zfs_scan_start_timestamp_seconds`},
+			scanResults: map[string]zfs.ScanStatus{
+				`testpool`: {
+					Function:     zfs.ScanFunctionUnknown,
+					State:        zfs.ScanStateUnknown,
+					Unrecognised: `error scrub in progress since Sun Oct 13 00:24:01 2024`,
+				},
+			},
+			metricResults: `# HELP zfs_scan_info Information about the most recent scrub or resilver of the pool.
+# TYPE zfs_scan_info gauge
+zfs_scan_info{function="unknown",pool="testpool",state="unknown"} 1
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return(tc.pools, nil).Times(1)
+			for _, pool := range tc.pools {
+				zfsPool := mock_zfs.NewMockPool(ctrl)
+				zfsPool.EXPECT().ScanStatus(gomock.Any()).Return(tc.scanResults[pool], nil).Times(1)
+				zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
+			}
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`scan`: {
+					Name:       "scan",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newScanCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultSnapshotHoldsProps = `creation,used`
+)
+
+var (
+	snapshotHeldLabels    = []string{`name`, `pool`}
+	snapshotHoldTagLabels = []string{`name`, `pool`, `tag`}
+
+	snapshotHeldMetrics = newSummaryMetrics(
+		`snapshot-holds`,
+		subsystemSnapshot,
+		`held_snapshots`,
+		`Number of snapshots of the dataset with at least one user hold, each counted once regardless of the number of holds, see "holds_by_tag" for the holds.`,
+		snapshotHeldLabels,
+		map[string][]summaryProperty{
+			`creation`: {
+				newSummaryProperty(
+					subsystemSnapshot,
+					`held_oldest_timestamp_seconds`,
+					`The unix timestamp when the oldest held snapshot of the dataset was created.`,
+					snapshotHeldLabels,
+					func(s *summary) float64 { return s.oldest },
+				),
+			},
+			`used`: {
+				newSummaryProperty(
+					subsystemSnapshot,
+					`held_used_bytes`,
+					`The sum of the space in bytes consumed uniquely by each held snapshot of the dataset.`,
+					snapshotHeldLabels,
+					func(s *summary) float64 { return s.used },
+				),
+			},
+		},
+	)
+	// The metrics for the holds are derived from the output of `zfs holds`, rather than requested properties
+	snapshotHoldTagProps   = []string{`timestamp`}
+	snapshotHoldTagMetrics = newSummaryMetrics(
+		`snapshot-holds`,
+		subsystemSnapshot,
+		`holds_by_tag`,
+		`Number of user holds with the tag on snapshots of the dataset, which is the number of snapshots held by the tag, see "held_snapshots" for the snapshots held by any tag.`,
+		snapshotHoldTagLabels,
+		map[string][]summaryProperty{
+			`timestamp`: {
+				newSummaryProperty(
+					subsystemSnapshot,
+					`hold_oldest_timestamp_seconds`,
+					`The unix timestamp when the oldest hold with the tag was placed on a snapshot of the dataset.`,
+					snapshotHoldTagLabels,
+					func(s *summary) float64 { return s.oldest },
+				),
+			},
+		},
+	)
+)
+
+func init() {
+	registerCollector(`snapshot-holds`, defaultDisabled, defaultSnapshotHoldsProps, newSnapshotHoldsCollector)
+	registerCollectorFilter(`snapshot-holds`, `dataset-snapshot`)
+}
+
+type snapshotHoldsCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+	filter datasetFilter
+}
+
+func (c *snapshotHoldsCollector) describe(ch chan<- *prometheus.Desc) {
+	snapshotHeldMetrics.describe(c.props, ch)
+	snapshotHoldTagMetrics.describe(snapshotHoldTagProps, ch)
+}
+
+func (c *snapshotHoldsCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool, roots.pool(pool), excludes); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *snapshotHoldsCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, roots []string, excludes regexpCollection) error {
+	snapshots, err := c.filter.datasets(ctx, c.client, pool, zfs.DatasetSnapshot, roots, excludes, c.requestProps()...)
+	if err != nil {
+		return err
+	}
+
+	// Only snapshots with user references are queried for their holds
+	held := make(datasetSummaries)
+	var names []string
+	for _, snapshot := range snapshots {
+		props := snapshot.Properties()
+		refs, err := transformNumeric(props[`userrefs`])
+		if err != nil {
+			return err
+		}
+		if refs <= 0 {
+			continue
+		}
+		name := snapshot.DatasetName()
+		dataset, _, ok := strings.Cut(name, `@`)
+		if !ok {
+			return fmt.Errorf("invalid snapshot name: %s", name)
+		}
+		if err = held.add(dataset, []string{dataset, pool}, props); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	holds, err := c.client.Holds(ctx, names...)
+	if err != nil {
+		return err
+	}
+	tags := make(datasetSummaries)
+	for _, hold := range holds {
+		dataset, _, _ := strings.Cut(hold.Snapshot, `@`)
+		tags.observe(dataset+`@`+hold.Tag, []string{dataset, pool, hold.Tag}, float64(hold.Timestamp), 0)
+	}
+	snapshotHeldMetrics.push(c.props, ch, held)
+	snapshotHoldTagMetrics.push(snapshotHoldTagProps, ch, tags)
+
+	return nil
+}
+
+// requestProps returns the properties to request for each snapshot, including the number of user holds
+func (c *snapshotHoldsCollector) requestProps() []string {
+	return append(append(make([]string, 0, len(c.props)+1), c.props...), `userrefs`)
+}
+
+func newSnapshotHoldsCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	filter, err := newDatasetFilter(options)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotHoldsCollector{log: l, client: c, props: snapshotHeldMetrics.supported(l, props), filter: filter}, nil
+}
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSnapshotHoldsMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		options        map[string]*string
+		excludes       []string
+		root           string
+		depth          int
+		metricNames    []string
+		propsResults   []datasetResults
+		held           []string
+		holds          []zfs.Hold
+		metricResults  string
+	}{
+		{
+			name:           `held snapshots`,
+			propsRequested: []string{`creation`, `used`},
+			metricNames: []string{
+				`zfs_snapshot_held_snapshots`,
+				`zfs_snapshot_held_oldest_timestamp_seconds`,
+				`zfs_snapshot_held_used_bytes`,
+				`zfs_snapshot_holds_by_tag`,
+				`zfs_snapshot_hold_oldest_timestamp_seconds`,
+			},
+			propsResults: []datasetResults{
+				{name: `testpool/home@daily-1`, results: map[string]string{`creation`: `1735689600`, `used`: `1024`, `userrefs`: `2`}},
+				{name: `testpool/home@daily-2`, results: map[string]string{`creation`: `1735776000`, `used`: `2048`, `userrefs`: `1`}},
+				{name: `testpool/home@daily-3`, results: map[string]string{`creation`: `1735862400`, `used`: `4096`, `userrefs`: `0`}},
+				{name: `testpool/var@manual`, results: map[string]string{`creation`: `1735000000`, `used`: `512`, `userrefs`: `0`}},
+			},
+			held: []string{`testpool/home@daily-1`, `testpool/home@daily-2`},
+			holds: []zfs.Hold{
+				// A recent hold on an old snapshot is reported with the creation of the snapshot
+				{Snapshot: `testpool/home@daily-1`, Tag: `backup-job`, Timestamp: 1735948800},
+				{Snapshot: `testpool/home@daily-1`, Tag: `keep`, Timestamp: 1735700000},
+				{Snapshot: `testpool/home@daily-2`, Tag: `backup-job`, Timestamp: 1735779600},
+			},
+			metricResults: `# HELP zfs_snapshot_held_oldest_timestamp_seconds The unix timestamp when the oldest held snapshot of the dataset was created.
+# TYPE zfs_snapshot_held_oldest_timestamp_seconds gauge
+zfs_snapshot_held_oldest_timestamp_seconds{name="testpool/home",pool="testpool"} 1.7356896e+09
+# HELP zfs_snapshot_held_snapshots Number of snapshots of the dataset with at least one user hold, each counted once regardless of the number of holds, see "holds_by_tag" for the holds.
+# TYPE zfs_snapshot_held_snapshots gauge
+zfs_snapshot_held_snapshots{name="testpool/home",pool="testpool"} 2
+# HELP zfs_snapshot_held_used_bytes The sum of the space in bytes consumed uniquely by each held snapshot of the dataset.
+# TYPE zfs_snapshot_held_used_bytes gauge
+zfs_snapshot_held_used_bytes{name="testpool/home",pool="testpool"} 3072
+# HELP zfs_snapshot_hold_oldest_timestamp_seconds The unix timestamp when the oldest hold with the tag was placed on a snapshot of the dataset.
+# TYPE zfs_snapshot_hold_oldest_timestamp_seconds gauge
+zfs_snapshot_hold_oldest_timestamp_seconds{name="testpool/home",pool="testpool",tag="backup-job"} 1.7357796e+09
+zfs_snapshot_hold_oldest_timestamp_seconds{name="testpool/home",pool="testpool",tag="keep"} 1.7357e+09
+# HELP zfs_snapshot_holds_by_tag Number of user holds with the tag on snapshots of the dataset, which is the number of snapshots held by the tag, see "held_snapshots" for the snapshots held by any tag.
+# TYPE zfs_snapshot_holds_by_tag gauge
+zfs_snapshot_holds_by_tag{name="testpool/home",pool="testpool",tag="backup-job"} 2
+zfs_snapshot_holds_by_tag{name="testpool/home",pool="testpool",tag="keep"} 1
+`,
+		},
+		{
+			name:           `dataset-snapshot filter`,
+			propsRequested: []string{`used`},
+			options: map[string]*string{
+				datasetIncludeOption: stringPointer(`^testpool/home@`),
+			},
+			excludes:    []string{`@manual$`},
+			root:        `testpool/home`,
+			depth:       -1,
+			metricNames: []string{`zfs_snapshot_held_snapshots`, `zfs_snapshot_held_used_bytes`, `zfs_snapshot_held_oldest_timestamp_seconds`},
+			propsResults: []datasetResults{
+				{name: `testpool/home@daily-1`, results: map[string]string{`used`: `1024`, `userrefs`: `1`}},
+				{name: `testpool/home@manual`, results: map[string]string{`used`: `2048`, `userrefs`: `1`}},
+				{name: `testpool/home/alice@daily-1`, results: map[string]string{`used`: `4096`, `userrefs`: `1`}},
+			},
+			held: []string{`testpool/home@daily-1`},
+			holds: []zfs.Hold{
+				{Snapshot: `testpool/home@daily-1`, Tag: `keep`, Timestamp: 1735693200},
+			},
+			metricResults: `# HELP zfs_snapshot_held_snapshots Number of snapshots of the dataset with at least one user hold, each counted once regardless of the number of holds, see "holds_by_tag" for the holds.
+# TYPE zfs_snapshot_held_snapshots gauge
+zfs_snapshot_held_snapshots{name="testpool/home",pool="testpool"} 1
+# HELP zfs_snapshot_held_used_bytes The sum of the space in bytes consumed uniquely by each held snapshot of the dataset.
+# TYPE zfs_snapshot_held_used_bytes gauge
+zfs_snapshot_held_used_bytes{name="testpool/home",pool="testpool"} 1024
+`,
+		},
+		{
+			name:           `no holds`,
+			propsRequested: []string{`creation`, `used`},
+			metricNames:    []string{`zfs_snapshot_held_snapshots`, `zfs_snapshot_holds_by_tag`},
+			propsResults: []datasetResults{
+				{name: `testpool/home@daily-1`, results: map[string]string{`creation`: `1735689600`, `used`: `1024`, `userrefs`: `0`}},
+			},
+			metricResults: ``,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+			config.Excludes = tc.excludes
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsDatasetResults := make([]zfs.DatasetProperties, len(tc.propsResults))
+			for i, propResults := range tc.propsResults {
+				zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+				zfsDatasetProperties.EXPECT().DatasetName().Return(propResults.name).MinTimes(1)
+				zfsDatasetProperties.EXPECT().Properties().Return(propResults.results).MaxTimes(1)
+				zfsDatasetResults[i] = zfsDatasetProperties
+			}
+			zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+			zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetSnapshot).Return(zfsDatasets).Times(1)
+			if tc.root != `` {
+				zfsRootDatasets := mock_zfs.NewMockDatasets(ctrl)
+				zfsDatasets.EXPECT().Root(tc.root, tc.depth).Return(zfsRootDatasets).Times(1)
+				zfsDatasets = zfsRootDatasets
+			}
+			zfsDatasets.EXPECT().Properties(gomock.Any(), append(tc.propsRequested, `userrefs`)).Return(zfsDatasetResults, nil).Times(1)
+			if len(tc.held) > 0 {
+				zfsClient.EXPECT().Holds(gomock.Any(), tc.held).Return(tc.holds, nil).Times(1)
+			}
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`dataset-snapshot`: {
+					Name:       "dataset-snapshot",
+					Enabled:    boolPointer(false),
+					Properties: stringPointer(``),
+					Options:    tc.options,
+					factory:    newSnapshotCollector,
+				},
+				`snapshot-holds`: {
+					Name:       "snapshot-holds",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newSnapshotHoldsCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
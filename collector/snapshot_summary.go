@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	snapshotSummaryGroupOption = `group`
+
+	defaultSnapshotSummaryProps = `creation,used`
+)
+
+var (
+	snapshotSummaryLabels = []string{`name`, `pool`, `group`}
+
+	snapshotSummaryMetrics = newSummaryMetrics(
+		`snapshot-summary`,
+		subsystemSnapshot,
+		`count`,
+		`Number of snapshots of the dataset.`,
+		snapshotSummaryLabels,
+		map[string][]summaryProperty{
+			`creation`: {
+				newSummaryProperty(
+					subsystemSnapshot,
+					`oldest_timestamp_seconds`,
+					`The unix timestamp when the oldest snapshot of the dataset was created.`,
+					snapshotSummaryLabels,
+					func(s *summary) float64 { return s.oldest },
+				),
+				newSummaryProperty(
+					subsystemSnapshot,
+					`newest_timestamp_seconds`,
+					`The unix timestamp when the newest snapshot of the dataset was created.`,
+					snapshotSummaryLabels,
+					func(s *summary) float64 { return s.newest },
+				),
+			},
+			`used`: {
+				newSummaryProperty(
+					subsystemSnapshot,
+					`used_bytes`,
+					`The sum of the space in bytes consumed uniquely by each snapshot of the dataset. Space shared by multiple snapshots is not included, see the "used_by_snapshot_bytes" dataset property.`,
+					snapshotSummaryLabels,
+					func(s *summary) float64 { return s.used },
+				),
+			},
+		},
+	)
+)
+
+func init() {
+	registerCollector(`snapshot-summary`, defaultDisabled, defaultSnapshotSummaryProps, newSnapshotSummaryCollector)
+	registerCollectorOption(
+		`snapshot-summary`,
+		snapshotSummaryGroupOption,
+		`Regex matched against snapshot names (after the '@') to group snapshots for the snapshot-summary collector, using the first capture group if present, otherwise the whole match (e.g. '_(hourly|daily|weekly|monthly)$'). Snapshots that do not match are reported with an empty group.`,
+		``,
+	)
+	registerCollectorFilter(`snapshot-summary`, `dataset-snapshot`)
+}
+
+type snapshotSummaryCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+	group  *regexp.Regexp
+	filter datasetFilter
+}
+
+func (c *snapshotSummaryCollector) describe(ch chan<- *prometheus.Desc) {
+	snapshotSummaryMetrics.describe(c.props, ch)
+}
+
+func (c *snapshotSummaryCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool, roots.pool(pool), excludes); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+// groupName returns the group for the snapshot name, as matched by the group regex
+func (c *snapshotSummaryCollector) groupName(snapshot string) string {
+	if c.group == nil {
+		return ``
+	}
+	match := c.group.FindStringSubmatch(snapshot)
+	switch {
+	case match == nil:
+		return ``
+	case len(match) > 1:
+		return match[1]
+	default:
+		return match[0]
+	}
+}
+
+func (c *snapshotSummaryCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, roots []string, excludes regexpCollection) error {
+	snapshots, err := c.filter.datasets(ctx, c.client, pool, zfs.DatasetSnapshot, roots, excludes, summaryRequestProps(c.props)...)
+	if err != nil {
+		return err
+	}
+
+	summaries := make(datasetSummaries)
+	for _, snapshot := range snapshots {
+		name := snapshot.DatasetName()
+		dataset, snapshotName, ok := strings.Cut(name, `@`)
+		if !ok {
+			return fmt.Errorf("invalid snapshot name: %s", name)
+		}
+		group := c.groupName(snapshotName)
+		if err := summaries.add(dataset+`@`+group, []string{dataset, pool, group}, snapshot.Properties()); err != nil {
+			return err
+		}
+	}
+	snapshotSummaryMetrics.push(c.props, ch, summaries)
+
+	return nil
+}
+
+func newSnapshotSummaryCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	filter, err := newDatasetFilter(options)
+	if err != nil {
+		return nil, err
+	}
+	collector := &snapshotSummaryCollector{log: l, client: c, props: snapshotSummaryMetrics.supported(l, props), filter: filter}
+	if group := options[snapshotSummaryGroupOption]; group != `` {
+		if collector.group, err = regexp.Compile(group); err != nil {
+			return nil, fmt.Errorf("invalid snapshot-summary group: %w", err)
+		}
+	}
+
+	return collector, nil
+}
@@ -0,0 +1,203 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSnapshotSummaryMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		group          string
+		options        map[string]*string
+		excludes       []string
+		root           string
+		depth          int
+		metricNames    []string
+		propsResults   []datasetResults
+		metricResults  string
+	}{
+		{
+			name:           `ungrouped`,
+			propsRequested: []string{`creation`, `used`},
+			metricNames:    []string{`zfs_snapshot_count`, `zfs_snapshot_newest_timestamp_seconds`, `zfs_snapshot_oldest_timestamp_seconds`, `zfs_snapshot_used_bytes`},
+			propsResults: []datasetResults{
+				{name: `testpool/home@autosnap_2025-01-01_00:00:00_daily`, results: map[string]string{`creation`: `1735689600`, `used`: `1024`}},
+				{name: `testpool/home@autosnap_2025-01-01_01:00:00_hourly`, results: map[string]string{`creation`: `1735693200`, `used`: `512`}},
+				{name: `testpool/home@autosnap_2025-01-02_00:00:00_daily`, results: map[string]string{`creation`: `1735776000`, `used`: `2048`}},
+				{name: `testpool/var@manual`, results: map[string]string{`creation`: `1735000000`, `used`: `4096`}},
+			},
+			metricResults: `# HELP zfs_snapshot_count Number of snapshots of the dataset.
+# TYPE zfs_snapshot_count gauge
+zfs_snapshot_count{group="",name="testpool/home",pool="testpool"} 3
+zfs_snapshot_count{group="",name="testpool/var",pool="testpool"} 1
+# HELP zfs_snapshot_newest_timestamp_seconds The unix timestamp when the newest snapshot of the dataset was created.
+# TYPE zfs_snapshot_newest_timestamp_seconds gauge
+zfs_snapshot_newest_timestamp_seconds{group="",name="testpool/home",pool="testpool"} 1.735776e+09
+zfs_snapshot_newest_timestamp_seconds{group="",name="testpool/var",pool="testpool"} 1.735e+09
+# HELP zfs_snapshot_oldest_timestamp_seconds The unix timestamp when the oldest snapshot of the dataset was created.
+# TYPE zfs_snapshot_oldest_timestamp_seconds gauge
+zfs_snapshot_oldest_timestamp_seconds{group="",name="testpool/home",pool="testpool"} 1.7356896e+09
+zfs_snapshot_oldest_timestamp_seconds{group="",name="testpool/var",pool="testpool"} 1.735e+09
+# HELP zfs_snapshot_used_bytes The sum of the space in bytes consumed uniquely by each snapshot of the dataset. Space shared by multiple snapshots is not included, see the "used_by_snapshot_bytes" dataset property.
+# TYPE zfs_snapshot_used_bytes gauge
+zfs_snapshot_used_bytes{group="",name="testpool/home",pool="testpool"} 3584
+zfs_snapshot_used_bytes{group="",name="testpool/var",pool="testpool"} 4096
+`,
+		},
+		{
+			name:           `grouped with excludes`,
+			propsRequested: []string{`creation`},
+			group:          `_(hourly|daily)$`,
+			excludes:       []string{`^testpool/var@`},
+			metricNames:    []string{`zfs_snapshot_count`, `zfs_snapshot_newest_timestamp_seconds`, `zfs_snapshot_oldest_timestamp_seconds`, `zfs_snapshot_used_bytes`},
+			propsResults: []datasetResults{
+				{name: `testpool/home@autosnap_2025-01-01_00:00:00_daily`, results: map[string]string{`creation`: `1735689600`}},
+				{name: `testpool/home@autosnap_2025-01-01_01:00:00_hourly`, results: map[string]string{`creation`: `1735693200`}},
+				{name: `testpool/home@autosnap_2025-01-02_00:00:00_daily`, results: map[string]string{`creation`: `1735776000`}},
+				{name: `testpool/home@manual`, results: map[string]string{`creation`: `1735000000`}},
+				{name: `testpool/var@manual`, results: map[string]string{`creation`: `1735000000`}},
+			},
+			metricResults: `# HELP zfs_snapshot_count Number of snapshots of the dataset.
+# TYPE zfs_snapshot_count gauge
+zfs_snapshot_count{group="",name="testpool/home",pool="testpool"} 1
+zfs_snapshot_count{group="daily",name="testpool/home",pool="testpool"} 2
+zfs_snapshot_count{group="hourly",name="testpool/home",pool="testpool"} 1
+# HELP zfs_snapshot_newest_timestamp_seconds The unix timestamp when the newest snapshot of the dataset was created.
+# TYPE zfs_snapshot_newest_timestamp_seconds gauge
+zfs_snapshot_newest_timestamp_seconds{group="",name="testpool/home",pool="testpool"} 1.735e+09
+zfs_snapshot_newest_timestamp_seconds{group="daily",name="testpool/home",pool="testpool"} 1.735776e+09
+zfs_snapshot_newest_timestamp_seconds{group="hourly",name="testpool/home",pool="testpool"} 1.7356932e+09
+# HELP zfs_snapshot_oldest_timestamp_seconds The unix timestamp when the oldest snapshot of the dataset was created.
+# TYPE zfs_snapshot_oldest_timestamp_seconds gauge
+zfs_snapshot_oldest_timestamp_seconds{group="",name="testpool/home",pool="testpool"} 1.735e+09
+zfs_snapshot_oldest_timestamp_seconds{group="daily",name="testpool/home",pool="testpool"} 1.7356896e+09
+zfs_snapshot_oldest_timestamp_seconds{group="hourly",name="testpool/home",pool="testpool"} 1.7356932e+09
+`,
+		},
+		{
+			name:           `dataset-snapshot filter`,
+			propsRequested: []string{`used`},
+			options: map[string]*string{
+				datasetIncludeOption: stringPointer(`^testpool/home@`),
+				datasetDepthOption:   stringPointer(`1`),
+			},
+			root:        `testpool/home`,
+			depth:       1,
+			metricNames: []string{`zfs_snapshot_count`, `zfs_snapshot_used_bytes`},
+			propsResults: []datasetResults{
+				{name: `testpool/home@daily-1`, results: map[string]string{`used`: `1024`}},
+				{name: `testpool/home@daily-2`, results: map[string]string{`used`: `2048`}},
+			},
+			metricResults: `# HELP zfs_snapshot_count Number of snapshots of the dataset.
+# TYPE zfs_snapshot_count gauge
+zfs_snapshot_count{group="",name="testpool/home",pool="testpool"} 2
+# HELP zfs_snapshot_used_bytes The sum of the space in bytes consumed uniquely by each snapshot of the dataset. Space shared by multiple snapshots is not included, see the "used_by_snapshot_bytes" dataset property.
+# TYPE zfs_snapshot_used_bytes gauge
+zfs_snapshot_used_bytes{group="",name="testpool/home",pool="testpool"} 3072
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+			config.Excludes = tc.excludes
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsDatasetResults := make([]zfs.DatasetProperties, len(tc.propsResults))
+			for i, propResults := range tc.propsResults {
+				zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+				zfsDatasetProperties.EXPECT().DatasetName().Return(propResults.name).MinTimes(1)
+				zfsDatasetProperties.EXPECT().Properties().Return(propResults.results).MaxTimes(1)
+				zfsDatasetResults[i] = zfsDatasetProperties
+			}
+			zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+			zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetSnapshot).Return(zfsDatasets).Times(1)
+			if tc.root != `` {
+				zfsRootDatasets := mock_zfs.NewMockDatasets(ctrl)
+				zfsDatasets.EXPECT().Root(tc.root, tc.depth).Return(zfsRootDatasets).Times(1)
+				zfsDatasets = zfsRootDatasets
+			}
+			zfsDatasets.EXPECT().Properties(gomock.Any(), tc.propsRequested).Return(zfsDatasetResults, nil).Times(1)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`dataset-snapshot`: {
+					Name:       "dataset-snapshot",
+					Enabled:    boolPointer(false),
+					Properties: stringPointer(``),
+					Options:    tc.options,
+					factory:    newSnapshotCollector,
+				},
+				`snapshot-summary`: {
+					Name:       "snapshot-summary",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					Options:    map[string]*string{snapshotSummaryGroupOption: stringPointer(tc.group)},
+					factory:    newSnapshotSummaryCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSnapshotSummaryInvalidGroup(t *testing.T) {
+	if _, err := newSnapshotSummaryCollector(logger, nil, []string{`used`}, map[string]string{snapshotSummaryGroupOption: `(`}); err == nil {
+		t.Fatal(`expected error, got nil`)
+	}
+}
+
+func TestSnapshotSummaryUnsupportedProperties(t *testing.T) {
+	const result = `# HELP zfs_snapshot_count Number of snapshots of the dataset.
+# TYPE zfs_snapshot_count gauge
+zfs_snapshot_count{group="",name="testpool/home",pool="testpool"} 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	config := defaultConfig(zfsClient)
+
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+	zfsDatasetProperties.EXPECT().DatasetName().Return(`testpool/home@daily`).MinTimes(1)
+	zfsDatasetProperties.EXPECT().Properties().Return(map[string]string{`name`: `testpool/home@daily`}).MaxTimes(1)
+	zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+	// The snapshots are listed by name when no supported properties are selected
+	zfsDatasets.EXPECT().Properties(gomock.Any(), []string{`name`}).Return([]zfs.DatasetProperties{zfsDatasetProperties}, nil).Times(1)
+	zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetSnapshot).Return(zfsDatasets).Times(1)
+
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`snapshot-summary`: {
+			Name:       "snapshot-summary",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`nonexistent`),
+			factory:    newSnapshotSummaryCollector,
+		},
+	}
+
+	if err = callCollector(ctx, collector, []byte(result), []string{`zfs_snapshot_count`}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,293 @@
+package collector
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	spaceLimitOption = `limit`
+
+	defaultSpaceProps = `used,quota,objused,objquota`
+	defaultSpaceLimit = `100`
+
+	// spaceConcurrency limits the number of datasets queried concurrently per pool
+	spaceConcurrency = 4
+)
+
+// errSpaceQuery is returned when the space accounting for a dataset could not be queried
+var errSpaceQuery = errors.New(`failed to query space accounting`)
+
+var spaceProperties = map[zfs.SpaceKind]propertyStore{
+	zfs.SpaceUser:    newSpaceProperties(zfs.SpaceUser, subsystemUserspace),
+	zfs.SpaceGroup:   newSpaceProperties(zfs.SpaceGroup, subsystemGroupspace),
+	zfs.SpaceProject: newSpaceProperties(zfs.SpaceProject, subsystemProjectspace),
+}
+
+// newSpaceProperties returns the supported properties for the space accounting kind, labelled by the dataset, pool and
+// the user, group or project
+func newSpaceProperties(kind zfs.SpaceKind, subsystem string) propertyStore {
+	labels := []string{`name`, `pool`, string(kind)}
+	return propertyStore{
+		defaultSubsystem: subsystem,
+		defaultLabels:    labels,
+		store: map[string]property{
+			`used`: newProperty(
+				subsystem,
+				`used_bytes`,
+				fmt.Sprintf("The amount of space in bytes consumed by the %s within the dataset.", kind),
+				transformNumeric,
+				prometheus.GaugeValue,
+				labels...,
+			),
+			`quota`: newProperty(
+				subsystem,
+				`quota_bytes`,
+				fmt.Sprintf("The maximum amount of space in bytes the %s can consume within the dataset, zero if unset.", kind),
+				transformNumeric,
+				prometheus.GaugeValue,
+				labels...,
+			),
+			`objused`: newProperty(
+				subsystem,
+				`used_objects`,
+				fmt.Sprintf("The number of objects owned by the %s within the dataset.", kind),
+				transformNumeric,
+				prometheus.GaugeValue,
+				labels...,
+			),
+			`objquota`: newProperty(
+				subsystem,
+				`quota_objects`,
+				fmt.Sprintf("The maximum number of objects the %s can own within the dataset, zero if unset.", kind),
+				transformNumeric,
+				prometheus.GaugeValue,
+				labels...,
+			),
+		},
+	}
+}
+
+func init() {
+	registerCollector(`userspace`, defaultDisabled, defaultSpaceProps, newUserspaceCollector)
+	registerCollector(`groupspace`, defaultDisabled, defaultSpaceProps, newGroupspaceCollector)
+	registerCollector(`projectspace`, defaultDisabled, defaultSpaceProps, newProjectspaceCollector)
+
+	for _, collector := range []string{`userspace`, `groupspace`, `projectspace`} {
+		registerCollectorOption(
+			collector,
+			datasetIncludeOption,
+			fmt.Sprintf("Only include filesystems that match the provided regex for the %s collector (e.g. '^tank/home/'). When anchored with '^', only the filesystems below the longest dataset name in the literal prefix are queried, which must exist.", collector),
+			``,
+		)
+		registerCollectorOption(
+			collector,
+			datasetExcludeOption,
+			fmt.Sprintf("Exclude filesystems that match the provided regex for the %s collector, in addition to --exclude.", collector),
+			``,
+		)
+		registerCollectorOption(
+			collector,
+			datasetDepthOption,
+			fmt.Sprintf("Only include filesystems at most this many levels below the pool root for the %s collector (default: unlimited).", collector),
+			``,
+		)
+		registerCollectorOption(
+			collector,
+			spaceLimitOption,
+			fmt.Sprintf("Maximum number of entries to report per filesystem for the %s collector, retaining those that have used the most space, or 0 for unlimited.", collector),
+			defaultSpaceLimit,
+		)
+	}
+}
+
+type spaceCollector struct {
+	name   string
+	kind   zfs.SpaceKind
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+	filter datasetFilter
+	limit  int
+}
+
+func (c *spaceCollector) describe(ch chan<- *prometheus.Desc) {
+	store := spaceProperties[c.kind]
+	store.describeSelected(c.log, c.name, c.props, ch)
+}
+
+func (c *spaceCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool, roots.pool(pool), excludes); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *spaceCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string, roots []string, excludes regexpCollection) error {
+	datasets, err := c.filter.datasets(ctx, c.client, pool, zfs.DatasetFilesystem, roots, excludes, `name`)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int64
+	)
+	errChan := make(chan error, len(datasets))
+	sem := make(chan struct{}, spaceConcurrency)
+	for _, dataset := range datasets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			err := c.updateDatasetMetrics(ctx, ch, pool, name)
+			switch {
+			case errors.Is(err, errSpaceQuery):
+				failed.Add(1)
+			case err != nil:
+				errChan <- err
+			}
+			<-sem
+			wg.Done()
+		}(dataset.DatasetName())
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// Failures are only tolerated for some datasets, so that a collector that is unable to query any is marked failed
+	if n := failed.Load(); n > 0 && n == int64(len(datasets)) {
+		return fmt.Errorf("%w for all %d datasets in pool %s", errSpaceQuery, n, pool)
+	}
+
+	return nil
+}
+
+// updateDatasetMetrics sends the space accounting metrics for the dataset. A failure to query the dataset is logged
+// and returns errSpaceQuery, so that the remaining datasets in the pool are still reported.
+func (c *spaceCollector) updateDatasetMetrics(ctx context.Context, ch chan<- metric, pool, name string) error {
+	usage, err := c.client.SpaceUsage(ctx, name, c.kind, c.requestProps()...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		c.log.Warn(`Error querying space accounting`, `collector`, c.name, `dataset`, name, `err`, err)
+		return fmt.Errorf("%w: %w", errSpaceQuery, err)
+	}
+	usage = c.truncate(name, usage)
+
+	store := spaceProperties[c.kind]
+	for _, u := range usage {
+		labelValues := []string{name, pool, u.Name}
+		for _, k := range c.props {
+			v, ok := u.Properties[k]
+			if !ok {
+				continue
+			}
+			if err = store.pushSelected(c.log, c.name, c.props, ch, k, v, labelValues...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// requestProps returns the properties to request for each entry, including `used` if required to apply the limit
+func (c *spaceCollector) requestProps() []string {
+	if c.limit <= 0 || slices.Contains(c.props, `used`) {
+		return c.props
+	}
+	return append(append(make([]string, 0, len(c.props)+1), c.props...), `used`)
+}
+
+// truncate returns the entries that have used the most space in the dataset, up to the limit
+func (c *spaceCollector) truncate(dataset string, usage []zfs.SpaceUsage) []zfs.SpaceUsage {
+	if c.limit <= 0 || len(usage) <= c.limit {
+		return usage
+	}
+	used := func(u zfs.SpaceUsage) float64 {
+		v, err := transformNumeric(u.Properties[`used`])
+		if err != nil {
+			return 0
+		}
+		return v
+	}
+	slices.SortStableFunc(usage, func(a, b zfs.SpaceUsage) int {
+		return cmp.Or(cmp.Compare(used(b), used(a)), cmp.Compare(a.Name, b.Name))
+	})
+	c.log.Debug(`Truncating space accounting entries`, `collector`, c.name, `dataset`, dataset, `entries`, len(usage), `limit`, c.limit)
+
+	return usage[:c.limit]
+}
+
+func newSpaceCollector(kind zfs.SpaceKind, l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	store, ok := spaceProperties[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown space accounting type: %s", kind)
+	}
+	name := string(kind) + `space`
+
+	filter, err := newDatasetFilter(options)
+	if err != nil {
+		return nil, err
+	}
+	collector := &spaceCollector{name: name, kind: kind, log: l, client: c, props: make([]string, 0, len(props)), filter: filter}
+	// The space accounting commands reject unknown properties, so unsupported properties are not requested
+	for _, k := range expandProperties(props, store.names(), false) {
+		if _, err = store.find(k); err != nil {
+			l.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, name, `property`, k, `err`, err)
+			continue
+		}
+		collector.props = append(collector.props, k)
+	}
+	if v := options[spaceLimitOption]; v != `` {
+		if collector.limit, err = strconv.Atoi(v); err != nil || collector.limit < 0 {
+			return nil, fmt.Errorf("invalid %s option, must be a non-negative integer: %s", spaceLimitOption, v)
+		}
+	}
+
+	return collector, nil
+}
+
+func newUserspaceCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return newSpaceCollector(zfs.SpaceUser, l, c, props, options)
+}
+
+func newGroupspaceCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return newSpaceCollector(zfs.SpaceGroup, l, c, props, options)
+}
+
+func newProjectspaceCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return newSpaceCollector(zfs.SpaceProject, l, c, props, options)
+}
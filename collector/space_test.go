@@ -0,0 +1,217 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSpaceMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		collector      string
+		kind           zfs.SpaceKind
+		factory        factoryFunc
+		props          string
+		requestProps   []string
+		options        map[string]*string
+		datasets       []string
+		root           string
+		depth          int
+		queried        []string
+		failed         []string
+		usage          []zfs.SpaceUsage
+		metricNames    []string
+		metricResults  string
+		expectedErrMsg string
+	}{
+		{
+			name:         `userspace`,
+			collector:    `userspace`,
+			kind:         zfs.SpaceUser,
+			factory:      newUserspaceCollector,
+			props:        defaultSpaceProps,
+			requestProps: []string{`used`, `quota`, `objused`, `objquota`},
+			datasets:     []string{`testpool/home`},
+			queried:      []string{`testpool/home`},
+			usage: []zfs.SpaceUsage{
+				{Name: `alice`, Properties: map[string]string{`used`: `1024`, `quota`: `4096`, `objused`: `12`, `objquota`: `none`}},
+			},
+			metricNames: []string{`zfs_userspace_used_bytes`, `zfs_userspace_quota_bytes`, `zfs_userspace_used_objects`, `zfs_userspace_quota_objects`},
+			metricResults: `# HELP zfs_userspace_quota_bytes The maximum amount of space in bytes the user can consume within the dataset, zero if unset.
+# TYPE zfs_userspace_quota_bytes gauge
+zfs_userspace_quota_bytes{name="testpool/home",pool="testpool",user="alice"} 4096
+# HELP zfs_userspace_quota_objects The maximum number of objects the user can own within the dataset, zero if unset.
+# TYPE zfs_userspace_quota_objects gauge
+zfs_userspace_quota_objects{name="testpool/home",pool="testpool",user="alice"} 0
+# HELP zfs_userspace_used_bytes The amount of space in bytes consumed by the user within the dataset.
+# TYPE zfs_userspace_used_bytes gauge
+zfs_userspace_used_bytes{name="testpool/home",pool="testpool",user="alice"} 1024
+# HELP zfs_userspace_used_objects The number of objects owned by the user within the dataset.
+# TYPE zfs_userspace_used_objects gauge
+zfs_userspace_used_objects{name="testpool/home",pool="testpool",user="alice"} 12
+`,
+		},
+		{
+			name:         `groupspace filtered`,
+			collector:    `groupspace`,
+			kind:         zfs.SpaceGroup,
+			factory:      newGroupspaceCollector,
+			props:        `used`,
+			requestProps: []string{`used`},
+			options: map[string]*string{
+				datasetIncludeOption: stringPointer(`^testpool/home/`),
+				datasetExcludeOption: stringPointer(`/scratch$`),
+			},
+			datasets: []string{`testpool/home/alice`, `testpool/home/scratch`},
+			root:     `testpool/home`,
+			depth:    -1,
+			queried:  []string{`testpool/home/alice`},
+			usage: []zfs.SpaceUsage{
+				{Name: `staff`, Properties: map[string]string{`used`: `2048`}},
+			},
+			metricNames: []string{`zfs_groupspace_used_bytes`},
+			metricResults: `# HELP zfs_groupspace_used_bytes The amount of space in bytes consumed by the group within the dataset.
+# TYPE zfs_groupspace_used_bytes gauge
+zfs_groupspace_used_bytes{group="staff",name="testpool/home/alice",pool="testpool"} 2048
+`,
+		},
+		{
+			name:         `projectspace limit`,
+			collector:    `projectspace`,
+			kind:         zfs.SpaceProject,
+			factory:      newProjectspaceCollector,
+			props:        `objused`,
+			requestProps: []string{`objused`, `used`},
+			options: map[string]*string{
+				spaceLimitOption: stringPointer(`2`),
+			},
+			datasets: []string{`testpool`},
+			queried:  []string{`testpool`},
+			usage: []zfs.SpaceUsage{
+				{Name: `1`, Properties: map[string]string{`used`: `512`, `objused`: `1`}},
+				{Name: `2`, Properties: map[string]string{`used`: `4096`, `objused`: `2`}},
+				{Name: `3`, Properties: map[string]string{`used`: `1024`, `objused`: `3`}},
+			},
+			metricNames: []string{`zfs_projectspace_used_objects`, `zfs_projectspace_used_bytes`},
+			metricResults: `# HELP zfs_projectspace_used_objects The number of objects owned by the project within the dataset.
+# TYPE zfs_projectspace_used_objects gauge
+zfs_projectspace_used_objects{name="testpool",pool="testpool",project="2"} 2
+zfs_projectspace_used_objects{name="testpool",pool="testpool",project="3"} 3
+`,
+		},
+		{
+			name:         `userspace dataset error`,
+			collector:    `userspace`,
+			kind:         zfs.SpaceUser,
+			factory:      newUserspaceCollector,
+			props:        `used`,
+			requestProps: []string{`used`},
+			datasets:     []string{`testpool/home`, `testpool/noacct`},
+			queried:      []string{`testpool/home`, `testpool/noacct`},
+			failed:       []string{`testpool/noacct`},
+			usage: []zfs.SpaceUsage{
+				{Name: `alice`, Properties: map[string]string{`used`: `1024`}},
+			},
+			metricNames: []string{`zfs_userspace_used_bytes`, `zfs_scrape_collector_success`},
+			metricResults: `# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded.
+# TYPE zfs_scrape_collector_success gauge
+zfs_scrape_collector_success{collector="userspace"} 1
+# HELP zfs_userspace_used_bytes The amount of space in bytes consumed by the user within the dataset.
+# TYPE zfs_userspace_used_bytes gauge
+zfs_userspace_used_bytes{name="testpool/home",pool="testpool",user="alice"} 1024
+`,
+		},
+		{
+			name:         `userspace all datasets error`,
+			collector:    `userspace`,
+			kind:         zfs.SpaceUser,
+			factory:      newUserspaceCollector,
+			props:        `used`,
+			requestProps: []string{`used`},
+			datasets:     []string{`testpool/home`, `testpool/noacct`},
+			queried:      []string{`testpool/home`, `testpool/noacct`},
+			failed:       []string{`testpool/home`, `testpool/noacct`},
+			metricNames:  []string{`zfs_userspace_used_bytes`, `zfs_scrape_collector_success`},
+			metricResults: `# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded.
+# TYPE zfs_scrape_collector_success gauge
+zfs_scrape_collector_success{collector="userspace"} 0
+`,
+		},
+		{
+			name:           `invalid limit`,
+			collector:      `userspace`,
+			kind:           zfs.SpaceUser,
+			factory:        newUserspaceCollector,
+			props:          `used`,
+			options:        map[string]*string{spaceLimitOption: stringPointer(`-1`)},
+			expectedErrMsg: `invalid limit option, must be a non-negative integer: -1`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.expectedErrMsg != `` {
+				options := make(map[string]string, len(tc.options))
+				for k, v := range tc.options {
+					options[k] = *v
+				}
+				if _, err := tc.factory(logger, nil, []string{tc.props}, options); err == nil || err.Error() != tc.expectedErrMsg {
+					t.Fatalf("expected error %q, got %v", tc.expectedErrMsg, err)
+				}
+				return
+			}
+
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+			config.DisableMetrics = false
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+			zfsDatasetResults := make([]zfs.DatasetProperties, 0, len(tc.datasets))
+			for _, name := range tc.datasets {
+				zfsDatasetProperties := mock_zfs.NewMockDatasetProperties(ctrl)
+				zfsDatasetProperties.EXPECT().DatasetName().Return(name).MinTimes(1)
+				zfsDatasetResults = append(zfsDatasetResults, zfsDatasetProperties)
+			}
+			zfsDatasets := mock_zfs.NewMockDatasets(ctrl)
+			zfsClient.EXPECT().Datasets(`testpool`, zfs.DatasetFilesystem).Return(zfsDatasets).Times(1)
+			if tc.root != `` {
+				zfsRootDatasets := mock_zfs.NewMockDatasets(ctrl)
+				zfsDatasets.EXPECT().Root(tc.root, tc.depth).Return(zfsRootDatasets).Times(1)
+				zfsDatasets = zfsRootDatasets
+			}
+			zfsDatasets.EXPECT().Properties(gomock.Any(), []string{`name`}).Return(zfsDatasetResults, nil).Times(1)
+			for _, name := range tc.queried {
+				if slices.Contains(tc.failed, name) {
+					zfsClient.EXPECT().SpaceUsage(gomock.Any(), name, tc.kind, tc.requestProps).Return(nil, errors.New(`failed`)).Times(1)
+					continue
+				}
+				zfsClient.EXPECT().SpaceUsage(gomock.Any(), name, tc.kind, tc.requestProps).Return(tc.usage, nil).Times(1)
+			}
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				tc.collector: {
+					Name:       tc.collector,
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(tc.props),
+					Options:    tc.options,
+					factory:    tc.factory,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// summaryProperty is a metric derived from the summary of the snapshots or bookmarks of a dataset
+type summaryProperty struct {
+	name  string
+	desc  *prometheus.Desc
+	value func(*summary) float64
+}
+
+func newSummaryProperty(subsystem, metricName, helpText string, labels []string, value func(*summary) float64) summaryProperty {
+	name := prometheus.BuildFQName(namespace, subsystem, metricName)
+	return summaryProperty{
+		name:  name,
+		desc:  prometheus.NewDesc(name, helpText, labels, nil),
+		value: value,
+	}
+}
+
+// summaryMetrics describes the metrics reported for the summaries of a collector, keyed by the ZFS property that
+// each metric is derived from
+type summaryMetrics struct {
+	collector  string
+	countName  string
+	countDesc  *prometheus.Desc
+	properties map[string][]summaryProperty
+}
+
+func newSummaryMetrics(collector, subsystem, countMetric, countHelp string, labels []string, properties map[string][]summaryProperty) summaryMetrics {
+	countName := prometheus.BuildFQName(namespace, subsystem, countMetric)
+	return summaryMetrics{
+		collector:  collector,
+		countName:  countName,
+		countDesc:  prometheus.NewDesc(countName, countHelp, labels, nil),
+		properties: properties,
+	}
+}
+
+// supported returns the requested properties that the summary metrics are derived from, logging those that are not
+func (m summaryMetrics) supported(l *slog.Logger, props []string) []string {
+	result := make([]string, 0, len(props))
+	for _, k := range expandProperties(props, slices.Sorted(maps.Keys(m.properties)), false) {
+		if _, ok := m.properties[k]; !ok {
+			l.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, m.collector, `property`, k, `err`, errUnsupportedProperty)
+			continue
+		}
+		result = append(result, k)
+	}
+
+	return result
+}
+
+func (m summaryMetrics) describe(props []string, ch chan<- *prometheus.Desc) {
+	ch <- m.countDesc
+	for _, k := range props {
+		for _, prop := range m.properties[k] {
+			ch <- prop.desc
+		}
+	}
+}
+
+// push sends the metrics for each of the summaries
+func (m summaryMetrics) push(props []string, ch chan<- metric, summaries datasetSummaries) {
+	for _, s := range summaries {
+		ch <- metric{
+			name:       expandMetricName(m.countName, s.labelValues...),
+			prometheus: prometheus.MustNewConstMetric(m.countDesc, prometheus.GaugeValue, float64(s.count), s.labelValues...),
+		}
+		for _, k := range props {
+			for _, prop := range m.properties[k] {
+				ch <- metric{
+					name:       expandMetricName(prop.name, s.labelValues...),
+					prometheus: prometheus.MustNewConstMetric(prop.desc, prometheus.GaugeValue, prop.value(s), s.labelValues...),
+				}
+			}
+		}
+	}
+}
+
+// summary aggregates the snapshots or bookmarks of a dataset
+type summary struct {
+	labelValues []string
+	count       uint64
+	used        float64
+	oldest      float64
+	newest      float64
+}
+
+// add aggregates an item created at the timestamp, in seconds since the epoch, that consumes the space in bytes
+func (s *summary) add(timestamp, used float64) {
+	s.count++
+	s.used += used
+	if s.count == 1 || timestamp < s.oldest {
+		s.oldest = timestamp
+	}
+	if timestamp > s.newest {
+		s.newest = timestamp
+	}
+}
+
+// datasetSummaries holds the summary for each key, as chosen by the collector
+type datasetSummaries map[string]*summary
+
+// add aggregates the `creation` and `used` properties of a snapshot or bookmark into the summary for the key
+func (m datasetSummaries) add(key string, labelValues []string, props map[string]string) error {
+	var creation, used float64
+	var err error
+	if v, ok := props[`creation`]; ok {
+		if creation, err = transformNumeric(v); err != nil {
+			return err
+		}
+	}
+	if v, ok := props[`used`]; ok {
+		if used, err = transformNumeric(v); err != nil {
+			return err
+		}
+	}
+	m.observe(key, labelValues, creation, used)
+
+	return nil
+}
+
+// observe aggregates an item into the summary for the key, which is created with the label values if required
+func (m datasetSummaries) observe(key string, labelValues []string, timestamp, used float64) {
+	s, ok := m[key]
+	if !ok {
+		s = &summary{labelValues: labelValues}
+		m[key] = s
+	}
+	s.add(timestamp, used)
+}
+
+// summaryRequestProps returns the properties to request for each snapshot or bookmark, at least one property is
+// required to list them
+func summaryRequestProps(props []string) []string {
+	if len(props) == 0 {
+		return []string{`name`}
+	}
+	return props
+}
@@ -17,6 +17,8 @@ const (
 	poolUnavail
 	poolRemoved
 	poolSuspended
+	vdevAvail
+	vdevInUse
 )
 
 func transformNumeric(value string) (float64, error) {
@@ -50,6 +52,17 @@ func transformHealthCode(status string) (float64, error) {
 	return float64(result), nil
 }
 
+func transformVdevHealthCode(status string) (float64, error) {
+	switch zfs.PoolStatus(status) {
+	case zfs.VdevAvail:
+		return float64(vdevAvail), nil
+	case zfs.VdevInUse:
+		return float64(vdevInUse), nil
+	}
+
+	return transformHealthCode(status)
+}
+
 func transformBool(value string) (float64, error) {
 	switch value {
 	case `on`, `yes`, `enabled`, `active`:
@@ -83,3 +96,11 @@ func transformMultiplier(value string) (float64, error) {
 	}
 	return 1 / v, nil
 }
+
+func transformNanoseconds(value string) (float64, error) {
+	v, err := transformNumeric(value)
+	if err != nil {
+		return -1, err
+	}
+	return v / 1e9, nil
+}
@@ -0,0 +1,287 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultTxgProps = `ndirty,nread,nwritten,otime,qtime,reads,stime,wtime,writes`
+)
+
+var (
+	txgCommittedDescName = prometheus.BuildFQName(namespace, subsystemTxg, `committed_total`)
+	txgCommittedDesc     = prometheus.NewDesc(
+		txgCommittedDescName,
+		`Number of transaction groups committed by the pool.`,
+		poolLabels,
+		nil,
+	)
+
+	txgProperties = map[string]txgProperty{
+		`ndirty`: newTxgHistogramProperty(
+			`dirty_bytes`,
+			`Distribution of the amount of dirty data in bytes written by committed transaction groups.`,
+			func(t zfs.Txg) float64 { return float64(t.Dirty) },
+			// 1MiB to 8GiB
+			prometheus.ExponentialBuckets(1<<20, 2, 14),
+		),
+		`nread`: newTxgCounterProperty(
+			`read_bytes_total`,
+			`Amount of data in bytes read by committed transaction groups.`,
+			func(t zfs.Txg) float64 { return float64(t.Read) },
+		),
+		`nwritten`: newTxgCounterProperty(
+			`written_bytes_total`,
+			`Amount of data in bytes written by committed transaction groups.`,
+			func(t zfs.Txg) float64 { return float64(t.Written) },
+		),
+		`otime`: newTxgCounterProperty(
+			`open_seconds_total`,
+			`Cumulative time in seconds that committed transaction groups spent open.`,
+			func(t zfs.Txg) float64 { return float64(t.OpenTime) / 1e9 },
+		),
+		`qtime`: newTxgCounterProperty(
+			`quiesce_seconds_total`,
+			`Cumulative time in seconds that committed transaction groups spent quiescing.`,
+			func(t zfs.Txg) float64 { return float64(t.QuiesceTime) / 1e9 },
+		),
+		`reads`: newTxgCounterProperty(
+			`reads_total`,
+			`Number of read operations issued by committed transaction groups.`,
+			func(t zfs.Txg) float64 { return float64(t.Reads) },
+		),
+		`stime`: newTxgHistogramProperty(
+			`sync_duration_seconds`,
+			`Distribution of the time in seconds that committed transaction groups spent syncing.`,
+			func(t zfs.Txg) float64 { return float64(t.SyncTime) / 1e9 },
+			// 10ms to ~41s
+			prometheus.ExponentialBuckets(0.01, 2, 13),
+		),
+		`wtime`: newTxgCounterProperty(
+			`wait_seconds_total`,
+			`Cumulative time in seconds that committed transaction groups spent waiting to sync.`,
+			func(t zfs.Txg) float64 { return float64(t.WaitTime) / 1e9 },
+		),
+		`writes`: newTxgCounterProperty(
+			`writes_total`,
+			`Number of write operations issued by committed transaction groups.`,
+			func(t zfs.Txg) float64 { return float64(t.Writes) },
+		),
+	}
+)
+
+func init() {
+	registerCollector(`txg`, defaultDisabled, defaultTxgProps, newTxgCollector)
+}
+
+// txgProperty describes a metric accumulated from the committed transaction groups of a pool. Properties with buckets
+// are exposed as histograms, and all others as counters.
+type txgProperty struct {
+	name    string
+	desc    *prometheus.Desc
+	value   func(zfs.Txg) float64
+	buckets []float64
+}
+
+func newTxgCounterProperty(metricName, helpText string, value func(zfs.Txg) float64) txgProperty {
+	name := prometheus.BuildFQName(namespace, subsystemTxg, metricName)
+	return txgProperty{
+		name:  name,
+		desc:  prometheus.NewDesc(name, helpText, poolLabels, nil),
+		value: value,
+	}
+}
+
+func newTxgHistogramProperty(metricName, helpText string, value func(zfs.Txg) float64, buckets []float64) txgProperty {
+	prop := newTxgCounterProperty(metricName, helpText, value)
+	prop.buckets = buckets
+	return prop
+}
+
+// txgAccumulator holds the running totals for a single property of a pool
+type txgAccumulator struct {
+	sum     float64
+	count   uint64
+	buckets map[float64]uint64
+}
+
+func (a *txgAccumulator) observe(prop txgProperty, value float64) {
+	a.sum += value
+	a.count++
+	for _, upper := range prop.buckets {
+		if value <= upper {
+			a.buckets[upper]++
+		}
+	}
+}
+
+// txgPoolState holds the running totals for a pool, and the last transaction group that was accounted for
+type txgPoolState struct {
+	last         uint64
+	committed    uint64
+	accumulators map[string]*txgAccumulator
+}
+
+func (s *txgPoolState) accumulator(name string) *txgAccumulator {
+	a, ok := s.accumulators[name]
+	if !ok {
+		a = &txgAccumulator{buckets: make(map[float64]uint64)}
+		s.accumulators[name] = a
+	}
+	return a
+}
+
+func newTxgPoolState() *txgPoolState {
+	return &txgPoolState{accumulators: make(map[string]*txgAccumulator)}
+}
+
+type txgCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+	pools  map[string]*txgPoolState
+	mu     sync.Mutex
+}
+
+func (c *txgCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- txgCommittedDesc
+	for _, k := range c.props {
+		prop, ok := txgProperties[k]
+		if !ok {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `txg`, `property`, k, `err`, errUnsupportedProperty)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *txgCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	c.prune(pools)
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+// prune discards state for pools that are no longer being collected.
+func (c *txgCollector) prune(pools []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	want := make(map[string]struct{}, len(pools))
+	for _, pool := range pools {
+		want[pool] = struct{}{}
+	}
+	for pool := range c.pools {
+		if _, ok := want[pool]; !ok {
+			delete(c.pools, pool)
+		}
+	}
+}
+
+func (c *txgCollector) poolState(pool string) *txgPoolState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.pools[pool]
+	if !ok {
+		state = newTxgPoolState()
+		c.pools[pool] = state
+	}
+	return state
+}
+
+func (c *txgCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
+	txgs, err := c.client.Pool(pool).Txgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	state := c.poolState(pool)
+
+	// Transaction group numbers only ever increase for a pool, so a lower number indicates that the pool has been
+	// recreated, and the totals are restarted.
+	var latest uint64
+	for _, txg := range txgs {
+		if txg.State == zfs.TxgCommitted && txg.TXG > latest {
+			latest = txg.TXG
+		}
+	}
+	if latest != 0 && latest < state.last {
+		c.log.Debug(`Transaction group history restarted`, `collector`, `txg`, `pool`, pool, `last`, state.last, `latest`, latest)
+		*state = *newTxgPoolState()
+	}
+
+	for _, txg := range txgs {
+		// Statistics are incomplete until the transaction group has been committed.
+		if txg.State != zfs.TxgCommitted || txg.TXG <= state.last {
+			continue
+		}
+		state.committed++
+		for _, k := range c.props {
+			prop, ok := txgProperties[k]
+			if !ok {
+				continue
+			}
+			state.accumulator(k).observe(prop, prop.value(txg))
+		}
+	}
+	if latest > state.last {
+		state.last = latest
+	}
+
+	labelValues := []string{pool}
+	ch <- metric{
+		name:       expandMetricName(txgCommittedDescName, labelValues...),
+		prometheus: prometheus.MustNewConstMetric(txgCommittedDesc, prometheus.CounterValue, float64(state.committed), labelValues...),
+	}
+	for _, k := range c.props {
+		prop, ok := txgProperties[k]
+		if !ok {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `txg`, `property`, k, `err`, errUnsupportedProperty)
+			continue
+		}
+		a := state.accumulator(k)
+		var m prometheus.Metric
+		if prop.buckets != nil {
+			buckets := make(map[float64]uint64, len(prop.buckets))
+			for _, upper := range prop.buckets {
+				buckets[upper] = a.buckets[upper]
+			}
+			m = prometheus.MustNewConstHistogram(prop.desc, a.count, a.sum, buckets, labelValues...)
+		} else {
+			m = prometheus.MustNewConstMetric(prop.desc, prometheus.CounterValue, a.sum, labelValues...)
+		}
+		ch <- metric{
+			name:       expandMetricName(prop.name, labelValues...),
+			prometheus: m,
+		}
+	}
+
+	return nil
+}
+
+func newTxgCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return &txgCollector{log: l, client: c, props: expandProperties(props, slices.Sorted(maps.Keys(txgProperties)), false), pools: make(map[string]*txgPoolState)}, nil
+}
@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTxgMetrics(t *testing.T) {
+	type scrape struct {
+		txgs          []zfs.Txg
+		metricResults string
+	}
+	testCases := []struct {
+		name           string
+		propsRequested []string
+		metricNames    []string
+		scrapes        []scrape
+	}{
+		{
+			name:           `counters across scrapes`,
+			propsRequested: []string{`nwritten`, `writes`, `wtime`},
+			metricNames:    []string{`zfs_txg_committed_total`, `zfs_txg_written_bytes_total`, `zfs_txg_writes_total`, `zfs_txg_wait_seconds_total`},
+			scrapes: []scrape{
+				{
+					txgs: []zfs.Txg{
+						{TXG: 100, State: zfs.TxgCommitted, Written: 4096, Writes: 2, WaitTime: 500000000},
+						{TXG: 101, State: zfs.TxgCommitted, Written: 8192, Writes: 3, WaitTime: 250000000},
+						{TXG: 102, State: zfs.TxgSyncing, Written: 1024, Writes: 1, WaitTime: 1000000000},
+						{TXG: 103, State: zfs.TxgOpen},
+					},
+					metricResults: `# HELP zfs_txg_committed_total Number of transaction groups committed by the pool.
+# TYPE zfs_txg_committed_total counter
+zfs_txg_committed_total{pool="testpool"} 2
+# HELP zfs_txg_wait_seconds_total Cumulative time in seconds that committed transaction groups spent waiting to sync.
+# TYPE zfs_txg_wait_seconds_total counter
+zfs_txg_wait_seconds_total{pool="testpool"} 0.75
+# HELP zfs_txg_writes_total Number of write operations issued by committed transaction groups.
+# TYPE zfs_txg_writes_total counter
+zfs_txg_writes_total{pool="testpool"} 5
+# HELP zfs_txg_written_bytes_total Amount of data in bytes written by committed transaction groups.
+# TYPE zfs_txg_written_bytes_total counter
+zfs_txg_written_bytes_total{pool="testpool"} 12288
+`,
+				},
+				{
+					txgs: []zfs.Txg{
+						{TXG: 101, State: zfs.TxgCommitted, Written: 8192, Writes: 3, WaitTime: 250000000},
+						{TXG: 102, State: zfs.TxgCommitted, Written: 2048, Writes: 1, WaitTime: 1000000000},
+						{TXG: 103, State: zfs.TxgSyncing},
+						{TXG: 104, State: zfs.TxgOpen},
+					},
+					metricResults: `# HELP zfs_txg_committed_total Number of transaction groups committed by the pool.
+# TYPE zfs_txg_committed_total counter
+zfs_txg_committed_total{pool="testpool"} 3
+# HELP zfs_txg_wait_seconds_total Cumulative time in seconds that committed transaction groups spent waiting to sync.
+# TYPE zfs_txg_wait_seconds_total counter
+zfs_txg_wait_seconds_total{pool="testpool"} 1.75
+# HELP zfs_txg_writes_total Number of write operations issued by committed transaction groups.
+# TYPE zfs_txg_writes_total counter
+zfs_txg_writes_total{pool="testpool"} 6
+# HELP zfs_txg_written_bytes_total Amount of data in bytes written by committed transaction groups.
+# TYPE zfs_txg_written_bytes_total counter
+zfs_txg_written_bytes_total{pool="testpool"} 14336
+`,
+				},
+				{
+					txgs: []zfs.Txg{
+						{TXG: 5, State: zfs.TxgCommitted, Written: 512, Writes: 1, WaitTime: 0},
+						{TXG: 6, State: zfs.TxgOpen},
+					},
+					metricResults: `# HELP zfs_txg_committed_total Number of transaction groups committed by the pool.
+# TYPE zfs_txg_committed_total counter
+zfs_txg_committed_total{pool="testpool"} 1
+# HELP zfs_txg_wait_seconds_total Cumulative time in seconds that committed transaction groups spent waiting to sync.
+# TYPE zfs_txg_wait_seconds_total counter
+zfs_txg_wait_seconds_total{pool="testpool"} 0
+# HELP zfs_txg_writes_total Number of write operations issued by committed transaction groups.
+# TYPE zfs_txg_writes_total counter
+zfs_txg_writes_total{pool="testpool"} 1
+# HELP zfs_txg_written_bytes_total Amount of data in bytes written by committed transaction groups.
+# TYPE zfs_txg_written_bytes_total counter
+zfs_txg_written_bytes_total{pool="testpool"} 512
+`,
+				},
+			},
+		},
+		{
+			name:           `sync time histogram`,
+			propsRequested: []string{`stime`},
+			metricNames:    []string{`zfs_txg_sync_duration_seconds`},
+			scrapes: []scrape{
+				{
+					txgs: []zfs.Txg{
+						{TXG: 1, State: zfs.TxgCommitted, SyncTime: 5000000},
+						{TXG: 2, State: zfs.TxgCommitted, SyncTime: 30000000},
+						{TXG: 3, State: zfs.TxgCommitted, SyncTime: 3000000000},
+					},
+					metricResults: `# HELP zfs_txg_sync_duration_seconds Distribution of the time in seconds that committed transaction groups spent syncing.
+# TYPE zfs_txg_sync_duration_seconds histogram
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.01"} 1
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.02"} 1
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.04"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.08"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.16"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.32"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="0.64"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="1.28"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="2.56"} 2
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="5.12"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="10.24"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="20.48"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="40.96"} 3
+zfs_txg_sync_duration_seconds_bucket{pool="testpool",le="+Inf"} 3
+zfs_txg_sync_duration_seconds_sum{pool="testpool"} 3.035
+zfs_txg_sync_duration_seconds_count{pool="testpool"} 3
+`,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`txg`: {
+					Name:       "txg",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newTxgCollector,
+				},
+			}
+
+			for _, s := range tc.scrapes {
+				zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+				zfsPool := mock_zfs.NewMockPool(ctrl)
+				zfsPool.EXPECT().Txgs(gomock.Any()).Return(s.txgs, nil).Times(1)
+				zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(1)
+
+				if err = callCollector(ctx, collector, []byte(s.metricResults), tc.metricNames); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
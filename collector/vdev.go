@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultVdevProps = `allocated,checksum_errors,free,health,read_errors,size,write_errors`
+)
+
+var (
+	vdevLabels     = []string{`pool`, `vdev`, `parent`}
+	vdevInfoLabels = []string{`pool`, `vdev`, `parent`, `type`, `class`}
+	vdevInfo       = newProperty(
+		subsystemVdev,
+		`info`,
+		`Information about the vdev or leaf device, including its type and allocation class.`,
+		transformNumeric,
+		prometheus.GaugeValue,
+		vdevInfoLabels...,
+	)
+	vdevProperties = propertyStore{
+		defaultSubsystem: subsystemVdev,
+		defaultLabels:    vdevLabels,
+		store: map[string]property{
+			`allocated`: newProperty(
+				subsystemVdev,
+				`allocated_bytes`,
+				`Amount of storage in bytes allocated on the vdev.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				vdevLabels...,
+			),
+			`checksum_errors`: newProperty(
+				subsystemVdev,
+				`checksum_errors_total`,
+				`Number of checksum errors reported by the vdev since the errors were last cleared.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				vdevLabels...,
+			),
+			`free`: newProperty(
+				subsystemVdev,
+				`free_bytes`,
+				`Amount of free storage in bytes on the vdev.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				vdevLabels...,
+			),
+			`health`: newProperty(
+				subsystemVdev,
+				`health`,
+				fmt.Sprintf("Health status code for the vdev [%d: %s, %d: %s, %d: %s, %d: %s, %d: %s, %d: %s, %d: %s, %d: %s, %d: %s].",
+					poolOnline, zfs.PoolOnline,
+					poolDegraded, zfs.PoolDegraded,
+					poolFaulted, zfs.PoolFaulted,
+					poolOffline, zfs.PoolOffline,
+					poolUnavail, zfs.PoolUnavail,
+					poolRemoved, zfs.PoolRemoved,
+					poolSuspended, zfs.PoolSuspended,
+					vdevAvail, zfs.VdevAvail,
+					vdevInUse, zfs.VdevInUse,
+				),
+				transformVdevHealthCode,
+				prometheus.GaugeValue,
+				vdevLabels...,
+			),
+			`read_errors`: newProperty(
+				subsystemVdev,
+				`read_errors_total`,
+				`Number of read errors reported by the vdev since the errors were last cleared.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				vdevLabels...,
+			),
+			`size`: newProperty(
+				subsystemVdev,
+				`size_bytes`,
+				`Total size in bytes of the vdev.`,
+				transformNumeric,
+				prometheus.GaugeValue,
+				vdevLabels...,
+			),
+			`write_errors`: newProperty(
+				subsystemVdev,
+				`write_errors_total`,
+				`Number of write errors reported by the vdev since the errors were last cleared.`,
+				transformNumeric,
+				prometheus.CounterValue,
+				vdevLabels...,
+			),
+		},
+	}
+)
+
+func init() {
+	registerCollector(`vdev`, defaultDisabled, defaultVdevProps, newVdevCollector)
+}
+
+type vdevCollector struct {
+	log    *slog.Logger
+	client zfs.Client
+	props  []string
+}
+
+func (c *vdevCollector) describe(ch chan<- *prometheus.Desc) {
+	ch <- vdevInfo.desc
+	for _, k := range c.props {
+		prop, err := vdevProperties.find(k)
+		if err != nil {
+			c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `vdev`, `property`, k, `err`, err)
+			continue
+		}
+		ch <- prop.desc
+	}
+}
+
+func (c *vdevCollector) update(ctx context.Context, ch chan<- metric, pools []string, roots datasetRoots, excludes regexpCollection) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(pools))
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool string) {
+			if err := c.updatePoolMetrics(ctx, ch, pool); err != nil {
+				errChan <- err
+			}
+			wg.Done()
+		}(pool)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *vdevCollector) updatePoolMetrics(ctx context.Context, ch chan<- metric, pool string) error {
+	vdevs, err := c.client.Pool(pool).Vdevs(ctx, c.props...)
+	if err != nil {
+		return err
+	}
+
+	for _, vdev := range vdevs {
+		labelValues := []string{pool, vdev.Name, vdev.Parent}
+		if err = vdevInfo.push(ch, `1`, append(labelValues, string(vdev.Type), string(vdev.Class))...); err != nil {
+			return err
+		}
+		for k, v := range vdev.Properties {
+			prop, err := vdevProperties.find(k)
+			if err != nil {
+				c.log.Warn(propertyUnsupportedMsg, `help`, helpIssue, `collector`, `vdev`, `property`, k, `err`, err)
+			}
+			if v == `-` {
+				// Not all properties are reported for every vdev type, ie - leaf devices of a mirror have no allocation.
+				continue
+			}
+			if err = prop.push(ch, v, labelValues...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newVdevCollector(l *slog.Logger, c zfs.Client, props []string, options map[string]string) (Collector, error) {
+	return &vdevCollector{log: l, client: c, props: expandProperties(props, vdevProperties.names(), false)}, nil
+}
@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVdevMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		pools          []string
+		propsRequested []string
+		metricNames    []string
+		vdevResults    map[string][]zfs.Vdev
+		metricResults  string
+	}{
+		{
+			name:           `all metrics`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`allocated`, `checksum_errors`, `free`, `health`, `read_errors`, `size`, `write_errors`},
+			metricNames:    []string{`zfs_vdev_info`, `zfs_vdev_allocated_bytes`, `zfs_vdev_checksum_errors_total`, `zfs_vdev_free_bytes`, `zfs_vdev_health`, `zfs_vdev_read_errors_total`, `zfs_vdev_size_bytes`, `zfs_vdev_write_errors_total`},
+			vdevResults: map[string][]zfs.Vdev{
+				`testpool`: {
+					{
+						Name:   `mirror-0`,
+						Parent: `testpool`,
+						Type:   zfs.VdevMirror,
+						Class:  zfs.VdevClassNormal,
+						Properties: map[string]string{
+							`allocated`:       `1024`,
+							`checksum_errors`: `0`,
+							`free`:            `1024`,
+							`health`:          `DEGRADED`,
+							`read_errors`:     `0`,
+							`size`:            `2048`,
+							`write_errors`:    `0`,
+						},
+					},
+					{
+						Name:   `sda`,
+						Parent: `mirror-0`,
+						Type:   zfs.VdevDisk,
+						Class:  zfs.VdevClassNormal,
+						Properties: map[string]string{
+							`allocated`:       `-`,
+							`checksum_errors`: `12`,
+							`free`:            `-`,
+							`health`:          `FAULTED`,
+							`read_errors`:     `3`,
+							`size`:            `2048`,
+							`write_errors`:    `1`,
+						},
+					},
+				},
+			},
+			metricResults: `# HELP zfs_vdev_allocated_bytes Amount of storage in bytes allocated on the vdev.
+# TYPE zfs_vdev_allocated_bytes gauge
+zfs_vdev_allocated_bytes{parent="testpool",pool="testpool",vdev="mirror-0"} 1024
+# HELP zfs_vdev_checksum_errors_total Number of checksum errors reported by the vdev since the errors were last cleared.
+# TYPE zfs_vdev_checksum_errors_total counter
+zfs_vdev_checksum_errors_total{parent="mirror-0",pool="testpool",vdev="sda"} 12
+zfs_vdev_checksum_errors_total{parent="testpool",pool="testpool",vdev="mirror-0"} 0
+# HELP zfs_vdev_free_bytes Amount of free storage in bytes on the vdev.
+# TYPE zfs_vdev_free_bytes gauge
+zfs_vdev_free_bytes{parent="testpool",pool="testpool",vdev="mirror-0"} 1024
+# HELP zfs_vdev_health Health status code for the vdev [0: ONLINE, 1: DEGRADED, 2: FAULTED, 3: OFFLINE, 4: UNAVAIL, 5: REMOVED, 6: SUSPENDED, 7: AVAIL, 8: INUSE].
+# TYPE zfs_vdev_health gauge
+zfs_vdev_health{parent="mirror-0",pool="testpool",vdev="sda"} 2
+zfs_vdev_health{parent="testpool",pool="testpool",vdev="mirror-0"} 1
+# HELP zfs_vdev_info Information about the vdev or leaf device, including its type and allocation class.
+# TYPE zfs_vdev_info gauge
+zfs_vdev_info{class="normal",parent="mirror-0",pool="testpool",type="disk",vdev="sda"} 1
+zfs_vdev_info{class="normal",parent="testpool",pool="testpool",type="mirror",vdev="mirror-0"} 1
+# HELP zfs_vdev_read_errors_total Number of read errors reported by the vdev since the errors were last cleared.
+# TYPE zfs_vdev_read_errors_total counter
+zfs_vdev_read_errors_total{parent="mirror-0",pool="testpool",vdev="sda"} 3
+zfs_vdev_read_errors_total{parent="testpool",pool="testpool",vdev="mirror-0"} 0
+# HELP zfs_vdev_size_bytes Total size in bytes of the vdev.
+# TYPE zfs_vdev_size_bytes gauge
+zfs_vdev_size_bytes{parent="mirror-0",pool="testpool",vdev="sda"} 2048
+zfs_vdev_size_bytes{parent="testpool",pool="testpool",vdev="mirror-0"} 2048
+# HELP zfs_vdev_write_errors_total Number of write errors reported by the vdev since the errors were last cleared.
+# TYPE zfs_vdev_write_errors_total counter
+zfs_vdev_write_errors_total{parent="mirror-0",pool="testpool",vdev="sda"} 1
+zfs_vdev_write_errors_total{parent="testpool",pool="testpool",vdev="mirror-0"} 0
+`,
+		},
+		{
+			name:           `spare states`,
+			pools:          []string{`testpool`},
+			propsRequested: []string{`health`},
+			metricNames:    []string{`zfs_vdev_health`},
+			vdevResults: map[string][]zfs.Vdev{
+				`testpool`: {
+					{
+						Name:       `sdb`,
+						Parent:     `testpool`,
+						Type:       zfs.VdevDisk,
+						Class:      zfs.VdevClassSpare,
+						Properties: map[string]string{`health`: `AVAIL`},
+					},
+					{
+						Name:       `sdc`,
+						Parent:     `testpool`,
+						Type:       zfs.VdevDisk,
+						Class:      zfs.VdevClassSpare,
+						Properties: map[string]string{`health`: `INUSE`},
+					},
+				},
+			},
+			metricResults: `# HELP zfs_vdev_health Health status code for the vdev [0: ONLINE, 1: DEGRADED, 2: FAULTED, 3: OFFLINE, 4: UNAVAIL, 5: REMOVED, 6: SUSPENDED, 7: AVAIL, 8: INUSE].
+# TYPE zfs_vdev_health gauge
+zfs_vdev_health{parent="testpool",pool="testpool",vdev="sdb"} 7
+zfs_vdev_health{parent="testpool",pool="testpool",vdev="sdc"} 8
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			zfsClient := mock_zfs.NewMockClient(ctrl)
+			config := defaultConfig(zfsClient)
+
+			zfsClient.EXPECT().PoolNames(gomock.Any()).Return(tc.pools, nil).Times(1)
+			for _, pool := range tc.pools {
+				zfsPool := mock_zfs.NewMockPool(ctrl)
+				zfsPool.EXPECT().Vdevs(gomock.Any(), tc.propsRequested).Return(tc.vdevResults[pool], nil).Times(1)
+				zfsClient.EXPECT().Pool(pool).Return(zfsPool).Times(1)
+			}
+
+			collector, err := NewZFS(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector.Collectors = map[string]State{
+				`vdev`: {
+					Name:       "vdev",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(strings.Join(tc.propsRequested, `,`)),
+					factory:    newVdevCollector,
+				},
+			}
+
+			if err = callCollector(ctx, collector, []byte(tc.metricResults), tc.metricNames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
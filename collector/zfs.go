@@ -2,6 +2,8 @@ package collector
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"regexp"
 	"sort"
@@ -25,14 +27,67 @@ func (c regexpCollection) MatchString(input string) bool {
 	return false
 }
 
+// datasetRoots holds the datasets below which dataset collectors query, none of which are nested
+type datasetRoots []string
+
+// pool returns the roots within the pool, or the pool itself if no roots are configured
+func (r datasetRoots) pool(pool string) []string {
+	if len(r) == 0 {
+		return []string{pool}
+	}
+	var result []string
+	for _, root := range r {
+		if root == pool || strings.HasPrefix(root, pool+`/`) {
+			result = append(result, root)
+		}
+	}
+	return result
+}
+
+// newDatasetRoots validates the provided dataset names, and removes any nested below another
+func newDatasetRoots(names []string) (datasetRoots, error) {
+	sorted := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSuffix(name, `/`)
+		if name == `` || strings.ContainsAny(name, `@#`) || strings.HasPrefix(name, `/`) {
+			return nil, fmt.Errorf("invalid dataset root: %s", name)
+		}
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	roots := make(datasetRoots, 0, len(sorted))
+	for _, name := range sorted {
+		if n := len(roots); n > 0 && (name == roots[n-1] || strings.HasPrefix(name, roots[n-1]+`/`)) {
+			continue
+		}
+		roots = append(roots, name)
+	}
+	return roots, nil
+}
+
 // ZFSConfig configures a ZFS collector
 type ZFSConfig struct {
 	DisableMetrics bool
 	Deadline       time.Duration
-	Pools          []string
-	Excludes       []string
+	// Timeout after which the commands executed by a collection run are killed, zero disables the timeout (default: no
+	// timeout)
+	Timeout  time.Duration
+	Pools    []string
+	Excludes []string
+	// DatasetRoots restricts dataset collectors to the named datasets and their descendents (default: all datasets in
+	// the selected pools)
+	DatasetRoots []string
+	// UserProperties are included as labels on the zfs_dataset_info metric of the dataset collectors
+	UserProperties []string
 	Logger         *slog.Logger
 	ZFSClient      zfs.Client
+	// Collectors to use, as returned by ConfigureCollectors (default: collector flags)
+	Collectors map[string]State
+	// CacheTimestamps exposes metrics served from the cache with the timestamp of their collection
+	CacheTimestamps bool
+	// PollInterval refreshes collectors in the background at the interval once started, rather than upon scrape
+	// (default: disabled)
+	PollInterval time.Duration
 }
 
 // ZFS collector
@@ -42,10 +97,34 @@ type ZFS struct {
 	client         zfs.Client
 	disableMetrics bool
 	deadline       time.Duration
-	cache          *metricCache
-	ready          chan struct{}
+	timeout        time.Duration
+	cacheTimes     bool
 	logger         *slog.Logger
 	excludes       regexpCollection
+	roots          datasetRoots
+	userProps      []string
+	instances      map[string]Collector
+	instancesMu    sync.Mutex
+	status         map[string]*collectorStatus
+	statusMu       sync.Mutex
+	pollInterval   time.Duration
+	pollCancel     context.CancelCauseFunc
+	pollMu         sync.Mutex
+	pollWg         sync.WaitGroup
+	// now returns the current time, and may be replaced for testing
+	now func() time.Time
+}
+
+// collectorStatus tracks the runs of a collector, guarded by ZFS.statusMu
+type collectorStatus struct {
+	// cache holds the metrics from the last completed run
+	cache *metricCache
+	// running is closed upon completion of the in-flight run, and is nil when the collector is idle
+	running   chan struct{}
+	started   time.Time
+	completed time.Time
+	succeeded time.Time
+	killed    uint64
 }
 
 // Describe implements the prometheus.Collector interface.
@@ -53,14 +132,18 @@ func (c *ZFS) Describe(ch chan<- *prometheus.Desc) {
 	if !c.disableMetrics {
 		ch <- scrapeDurationDesc
 		ch <- scrapeSuccessDesc
+		ch <- scrapeKilledDesc
+		ch <- scrapeLastSuccessDesc
+		ch <- scrapeCacheAgeDesc
+		ch <- scrapeStaleDesc
 	}
 
-	for _, state := range c.Collectors {
+	for name, state := range c.Collectors {
 		if !*state.Enabled {
 			continue
 		}
 
-		collector, err := state.factory(c.logger, c.client, strings.Split(*state.Properties, `,`))
+		collector, err := c.instance(name, state)
 		if err != nil {
 			continue
 		}
@@ -68,113 +151,292 @@ func (c *ZFS) Describe(ch chan<- *prometheus.Desc) {
 	}
 }
 
-// Collect implements the prometheus.Collector interface.
+// Collect implements the prometheus.Collector interface. Collectors that are due are run, and those that complete
+// within their deadline are served fresh, otherwise the cached metrics from their last completed run are served. Runs
+// continue in the background after the deadline, to update the cache upon completion, and a collector never runs
+// concurrently with itself, so that when a system is running slowly, we don't compound the problem.
 func (c *ZFS) Collect(ch chan<- prometheus.Metric) {
-	select {
-	case <-c.ready:
-	default:
-		c.sendCached(ch, make(map[string]struct{}))
+	if c.pollInterval > 0 {
+		c.collectPolled(ch)
 		return
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), c.deadline)
-	defer cancel()
+	begin := c.now()
 
-	cache := newMetricCache()
-	proxy := make(chan metric)
-	// Synchronize on collector completion.
-	wg := sync.WaitGroup{}
-	wg.Add(len(c.Collectors))
-	// Synchonize after timeout event, ensuring no writers are still active when we return control.
-	timeout := make(chan struct{})
-	finalized := make(chan struct{})
-	finalize := func() {
+	type pending struct {
+		name     string
+		done     <-chan struct{}
+		deadline time.Duration
+	}
+	due := make(map[string]Collector)
+	for name, state := range c.Collectors {
+		if !*state.Enabled {
+			continue
+		}
+		// Instantiate before marking the collector as running, so that it is never left running upon error
+		collector, err := c.instance(name, state)
+		if err != nil {
+			c.logger.Error("Error instantiating collector", "collector", name, "err", err)
+			continue
+		}
+		if !c.due(name, state, begin) {
+			continue
+		}
+		due[name] = collector
+	}
+
+	var started []pending
+	if len(due) > 0 {
+		pools := c.discoverPools()
+		for name, collector := range due {
+			state := c.Collectors[name]
+			deadline, _ := state.deadline(c.deadline)
+			started = append(started, pending{
+				name:     name,
+				done:     c.start(name, collector, pools, deadline),
+				deadline: deadline,
+			})
+		}
+	}
+
+	// Wait for each collector that was started to complete, or exceed its deadline
+	fresh := make(map[string]struct{}, len(started))
+	for _, p := range started {
+		timer := time.NewTimer(p.deadline - c.now().Sub(begin))
 		select {
-		case <-finalized:
-		default:
-			close(finalized)
+		case <-p.done:
+			fresh[p.name] = struct{}{}
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+
+	stale := make(map[string]struct{})
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	for name, state := range c.Collectors {
+		if !*state.Enabled {
+			continue
+		}
+		status := c.collectorStatus(name)
+		_, isFresh := fresh[name]
+		if !isFresh && status.running != nil {
+			stale[name] = struct{}{}
 		}
+		c.sendCached(ch, status.cache, isFresh)
 	}
+	c.sendStatus(ch, fresh, stale)
+}
+
+// due determines whether the named collector should be run, ie - it is not already running, and its interval has
+// elapsed since its last run started. The collector is marked as running if so.
+func (c *ZFS) due(name string, state State, now time.Time) bool {
+	interval, _ := state.interval(0)
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	status := c.collectorStatus(name)
+	if status.running != nil {
+		return false
+	}
+	if !status.started.IsZero() && now.Sub(status.started) < interval {
+		return false
+	}
+	status.running = make(chan struct{})
+	status.started = now
+	return true
+}
+
+// poolDiscovery holds the pools discovered for the collectors started by a scrape, available once done is closed
+type poolDiscovery struct {
+	done  chan struct{}
+	pools []string
+	err   error
+}
 
-	// Close the proxy channel upon collector completion.
+// discoverPools discovers the pools in the background, such that a hung command delays only the collectors waiting
+// on the result, which are served from the cache upon exceeding their deadline, rather than the scrape
+func (c *ZFS) discoverPools() *poolDiscovery {
+	d := &poolDiscovery{done: make(chan struct{})}
 	go func() {
-		wg.Wait()
-		close(proxy)
+		defer close(d.done)
+		ctx, cancel := c.runContext(context.Background())
+		defer cancel()
+		d.pools, d.err = c.getPools(ctx, c.Pools)
 	}()
+	return d
+}
+
+// start runs the named collector in the background once the pools are discovered, which must have been marked as
+// running, and returns a channel that is closed upon completion. The cached metrics for the collector are replaced upon
+// completion.
+func (c *ZFS) start(name string, collector Collector, pools *poolDiscovery, deadline time.Duration) <-chan struct{} {
+	c.statusMu.Lock()
+	done := c.collectorStatus(name).running
+	c.statusMu.Unlock()
 
-	// Cache metrics as they come in via the proxy channel, and ship them out if we've not exceeded the deadline.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deadline)
+		defer cancel()
+		<-pools.done
+		// Commands are not bound to the deadline, so that they may complete and update the cache
+		runCtx, runCancel := c.runContext(context.Background())
+		defer runCancel()
+		c.run(runCtx, ctx, name, collector, pools.pools, pools.err)
+	}()
+
+	return done
+}
+
+// run executes the named collector, replacing its cached metrics, and signalling completion to any waiters. The
+// collector is considered delayed if it completes after ctx is done.
+func (c *ZFS) run(runCtx, ctx context.Context, name string, collector Collector, pools []string, poolErr error) {
+	runCtx = zfs.WithKilledFunc(runCtx, func() {
+		// Commands are also killed when polling is stopped, which is not a failure of the command
+		if !errors.Is(context.Cause(runCtx), errPollStopped) {
+			c.commandKilled(name)
+		}
+	})
+
+	cache := newMetricCache()
+	proxy := make(chan metric)
+	done := make(chan struct{})
 	go func() {
 		for metric := range proxy {
 			cache.add(metric)
-			select {
-			case <-timeout:
-				finalize()
-			default:
-				ch <- metric.prometheus
-			}
 		}
-		// Signal completion and update full cache.
-		c.cache.replace(cache)
-		cancel()
-		// Notify next collection that we're ready to collect again
-		c.ready <- struct{}{}
+		close(done)
 	}()
 
-	pools, poolErr := c.getPools(c.Pools)
+	var err error
+	if poolErr != nil {
+		err = poolErr
+		c.publishCollectorMetrics(ctx, name, err, 0, proxy)
+	} else {
+		err = c.execute(runCtx, ctx, name, collector, proxy, pools)
+	}
+	close(proxy)
+	<-done
+
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	status := c.collectorStatus(name)
+	status.cache = cache
+	status.completed = c.now()
+	if err == nil {
+		status.succeeded = status.completed
+	}
+	if status.running != nil {
+		close(status.running)
+		status.running = nil
+	}
+}
+
+// collectorStatus returns the status for the named collector, which must be called with statusMu held
+func (c *ZFS) collectorStatus(name string) *collectorStatus {
+	status, ok := c.status[name]
+	if !ok {
+		status = &collectorStatus{cache: newMetricCache()}
+		c.status[name] = status
+	}
+	return status
+}
 
+// sendStatus sends the staleness metrics for the enabled collectors, which must be called with statusMu held. Those
+// that appear in fresh completed a run during the scrape, and those that appear in stale have a run in progress that
+// did not complete before the deadline.
+func (c *ZFS) sendStatus(ch chan<- prometheus.Metric, fresh, stale map[string]struct{}) {
+	if c.disableMetrics {
+		return
+	}
+	now := c.now()
 	for name, state := range c.Collectors {
 		if !*state.Enabled {
-			wg.Done()
 			continue
 		}
-
-		if poolErr != nil {
-			c.publishCollectorMetrics(ctx, name, poolErr, 0, proxy)
-			wg.Done()
-			continue
+		status := c.collectorStatus(name)
+		var isStale, age, lastSuccess float64
+		if _, ok := stale[name]; ok {
+			isStale = 1
+		}
+		if _, ok := fresh[name]; !ok && !status.completed.IsZero() {
+			age = now.Sub(status.completed).Seconds()
+		}
+		if !status.succeeded.IsZero() {
+			lastSuccess = float64(status.succeeded.UnixNano()) / 1e9
 		}
+		ch <- prometheus.MustNewConstMetric(scrapeStaleDesc, prometheus.GaugeValue, isStale, name)
+		ch <- prometheus.MustNewConstMetric(scrapeCacheAgeDesc, prometheus.GaugeValue, age, name)
+		ch <- prometheus.MustNewConstMetric(scrapeLastSuccessDesc, prometheus.GaugeValue, lastSuccess, name)
+	}
+}
 
-		collector, err := state.factory(c.logger, c.client, strings.Split(*state.Properties, `,`))
-		if err != nil {
-			c.logger.Error("Error instantiating collector", "collector", name, "err", err)
-			wg.Done()
-			continue
+// instance returns the collector instance for the named collector, instantiating it on first use. Instances are
+// retained so that collectors may track state between scrapes.
+func (c *ZFS) instance(name string, state State) (Collector, error) {
+	c.instancesMu.Lock()
+	defer c.instancesMu.Unlock()
+	if collector, ok := c.instances[name]; ok {
+		return collector, nil
+	}
+
+	collector, err := state.factory(c.logger, c.client, strings.Split(*state.Properties, `,`), collectorOptions(name, state, c.Collectors, c.userProps))
+	if err != nil {
+		return nil, err
+	}
+	c.instances[name] = collector
+
+	return collector, nil
+}
+
+// collectorOptions returns the options for the named collector, including the user properties nominated for the
+// dataset collectors, which are shared so that the zfs_dataset_info metric is consistent, and the dataset filter
+// options of the collector whose filter it shares, if any
+func collectorOptions(name string, state State, collectors map[string]State, userProps []string) map[string]string {
+	options := state.options()
+	if len(userProps) > 0 {
+		options[datasetUserPropsOption] = strings.Join(userProps, `,`)
+	}
+	if source, ok := collectorFilters[name]; ok {
+		for _, option := range []string{datasetIncludeOption, datasetExcludeOption, datasetDepthOption} {
+			if v := collectors[source].Options[option]; v != nil {
+				options[option] = *v
+			}
 		}
-		go func(name string, collector Collector) {
-			c.execute(ctx, name, collector, proxy, pools)
-			wg.Done()
-		}(name, collector)
-	}
-
-	// Wait for completion or timeout
-	<-ctx.Done()
-	err := ctx.Err()
-	if err == context.Canceled {
-		finalize()
-	} else if err != nil {
-		// Upon exceeding deadline, send cached data for any metrics that have not already been reported.
-		close(timeout) // assert timeout for flow control in other goroutines
-		c.cache.merge(cache)
-		cacheIndex := cache.index()
-		c.sendCached(ch, cacheIndex)
-	}
-	// Ensure there are no in-flight writes to the upstream channel
-	<-finalized
+	}
+	return options
+}
+
+// runContext returns the context for the commands executed by a collection run, which are killed upon exceeding the
+// timeout, so that a hung command does not block subsequent collection runs.
+func (c *ZFS) runContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(parent, c.timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// commandKilled records a command killed upon exceeding the timeout for the named collector
+func (c *ZFS) commandKilled(name string) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.collectorStatus(name).killed++
 }
 
-// sendCached values that do not appear in the current cacheIndex.
-func (c *ZFS) sendCached(ch chan<- prometheus.Metric, cacheIndex map[string]struct{}) {
-	c.cache.RLock()
-	defer c.cache.RUnlock()
-	for name, metric := range c.cache.cache {
-		if _, ok := cacheIndex[name]; ok {
+// sendCached sends the metrics from cache. Metrics that were not collected during the current scrape are sent with
+// the timestamp of their collection, if configured.
+func (c *ZFS) sendCached(ch chan<- prometheus.Metric, cache *metricCache, fresh bool) {
+	cache.RLock()
+	defer cache.RUnlock()
+	for _, metric := range cache.cache {
+		if c.cacheTimes && !fresh {
+			ch <- prometheus.NewMetricWithTimestamp(metric.collected, metric.Metric)
 			continue
 		}
-		ch <- metric
+		ch <- metric.Metric
 	}
 }
 
-func (c *ZFS) getPools(pools []string) ([]string, error) {
-	poolNames, err := c.client.PoolNames()
+func (c *ZFS) getPools(ctx context.Context, pools []string) ([]string, error) {
+	poolNames, err := c.client.PoolNames(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -202,12 +464,13 @@ func (c *ZFS) getPools(pools []string) ([]string, error) {
 	return result, nil
 }
 
-func (c *ZFS) execute(ctx context.Context, name string, collector Collector, ch chan<- metric, pools []string) {
+func (c *ZFS) execute(runCtx, ctx context.Context, name string, collector Collector, ch chan<- metric, pools []string) error {
 	begin := time.Now()
-	err := collector.update(ch, pools, c.excludes)
+	err := collector.update(runCtx, ch, pools, c.roots, c.excludes)
 	duration := time.Since(begin)
 
 	c.publishCollectorMetrics(ctx, name, err, duration, ch)
+	return err
 }
 
 func (c *ZFS) publishCollectorMetrics(ctx context.Context, name string, err error, duration time.Duration, ch chan<- metric) {
@@ -243,6 +506,13 @@ func (c *ZFS) publishCollectorMetrics(ctx context.Context, name string, err erro
 		name:       scrapeSuccessDescName,
 		prometheus: prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name),
 	}
+	c.statusMu.Lock()
+	killed := c.collectorStatus(name).killed
+	c.statusMu.Unlock()
+	ch <- metric{
+		name:       expandMetricName(scrapeKilledDescName, name),
+		prometheus: prometheus.MustNewConstMetric(scrapeKilledDesc, prometheus.CounterValue, float64(killed), name),
+	}
 }
 
 // NewZFS instantiates a ZFS collector with the provided ZFSConfig
@@ -251,19 +521,48 @@ func NewZFS(config ZFSConfig) (*ZFS, error) {
 	sort.Strings(config.Excludes)
 	excludes := make(regexpCollection, len(config.Excludes))
 	for i, v := range config.Excludes {
-		excludes[i] = regexp.MustCompile(v)
+		var err error
+		if excludes[i], err = regexp.Compile(v); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+	}
+	roots, err := newDatasetRoots(config.DatasetRoots)
+	if err != nil {
+		return nil, err
+	}
+	if config.Collectors == nil {
+		config.Collectors = collectorStates
+	}
+	// Ensure collectors can be instantiated with the provided configuration
+	for name, state := range config.Collectors {
+		if !*state.Enabled {
+			continue
+		}
+		if _, err := state.deadline(config.Deadline); err != nil {
+			return nil, fmt.Errorf("invalid deadline for collector %s: %w", name, err)
+		}
+		if _, err := state.interval(config.PollInterval); err != nil {
+			return nil, fmt.Errorf("invalid interval for collector %s: %w", name, err)
+		}
+		if _, err := state.factory(config.Logger, config.ZFSClient, strings.Split(*state.Properties, `,`), collectorOptions(name, state, config.Collectors, config.UserProperties)); err != nil {
+			return nil, fmt.Errorf("invalid configuration for collector %s: %w", name, err)
+		}
 	}
-	ready := make(chan struct{}, 1)
-	ready <- struct{}{}
 	return &ZFS{
 		disableMetrics: config.DisableMetrics,
 		client:         config.ZFSClient,
 		deadline:       config.Deadline,
+		timeout:        config.Timeout,
+		cacheTimes:     config.CacheTimestamps,
 		Pools:          config.Pools,
-		Collectors:     collectorStates,
+		Collectors:     config.Collectors,
 		excludes:       excludes,
-		cache:          newMetricCache(),
-		ready:          ready,
+		roots:          roots,
+		userProps:      config.UserProperties,
+		instances:      make(map[string]Collector),
+		status:         make(map[string]*collectorStatus),
 		logger:         config.Logger,
+		pollInterval:   config.PollInterval,
+		now:            time.Now,
 	}, nil
 }
@@ -3,9 +3,17 @@ package collector
 import (
 	"context"
 	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/pdf/zfs_exporter/v2/zfs"
 	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"go.uber.org/mock/gomock"
 )
 
@@ -20,7 +28,7 @@ zfs_scrape_collector_success{collector="pool"} 0
 
 	ctrl, ctx := gomock.WithContext(context.Background(), t)
 	zfsClient := mock_zfs.NewMockClient(ctrl)
-	zfsClient.EXPECT().PoolNames().Return(nil, errors.New(`Error returned from PoolNames()`)).Times(1)
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return(nil, errors.New(`Error returned from PoolNames()`)).Times(1)
 
 	config := defaultConfig(zfsClient)
 	config.DisableMetrics = false
@@ -41,3 +49,319 @@ zfs_scrape_collector_success{collector="pool"} 0
 		t.Fatal(err)
 	}
 }
+
+// hungRunner is a zfs.Runner that lists a single pool, and blocks on all other commands until cancelled
+type hungRunner struct{}
+
+func (hungRunner) Run(ctx context.Context, stdout io.Writer, name string, arg ...string) error {
+	if name == `zpool` && len(arg) > 0 && arg[0] == `list` {
+		_, err := io.WriteString(stdout, "testpool\n")
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestZFSCollectTimeout(t *testing.T) {
+	zfsClient, err := zfs.New(zfs.Config{Backend: zfs.BackendText, Runner: hungRunner{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := defaultConfig(zfsClient)
+	config.DisableMetrics = false
+	config.Timeout = 50 * time.Millisecond
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`health`),
+			factory:    newPoolCollector,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Each collection run should be killed at the timeout, allowing the next run to start
+	for _, killed := range []string{`1`, `2`} {
+		result := `# HELP zfs_scrape_collector_killed_commands_total zfs_exporter: Number of commands killed upon exceeding the collector timeout.
+# TYPE zfs_scrape_collector_killed_commands_total counter
+zfs_scrape_collector_killed_commands_total{collector="pool"} ` + killed + `
+# HELP zfs_scrape_collector_success zfs_exporter: Whether a collector succeeded.
+# TYPE zfs_scrape_collector_success gauge
+zfs_scrape_collector_success{collector="pool"} 0
+`
+		if err = callCollector(ctx, collector, []byte(result), []string{`zfs_scrape_collector_killed_commands_total`, `zfs_scrape_collector_success`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestZFSCollectStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(2)
+	zfsPoolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+	zfsPoolProperties.EXPECT().Properties().Return(map[string]string{`health`: `ONLINE`}).Times(2)
+	release := make(chan struct{})
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	gomock.InOrder(
+		zfsPool.EXPECT().Properties(gomock.Any(), `health`).Return(zfsPoolProperties, nil),
+		zfsPool.EXPECT().Properties(gomock.Any(), `health`).DoAndReturn(func(context.Context, ...string) (zfs.PoolProperties, error) {
+			<-release
+			return zfsPoolProperties, nil
+		}),
+	)
+	zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(2)
+
+	config := defaultConfig(zfsClient)
+	config.DisableMetrics = false
+	config.Deadline = time.Minute
+	config.CacheTimestamps = true
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clock atomic.Int64
+	clock.Store(1735689600)
+	collector.now = func() time.Time { return time.Unix(clock.Load(), 0) }
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`health`),
+			factory:    newPoolCollector,
+		},
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	gather := func() map[string]*dto.Metric {
+		t.Helper()
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := make(map[string]*dto.Metric, len(families))
+		for _, family := range families {
+			result[family.GetName()] = family.GetMetric()[0]
+		}
+		return result
+	}
+
+	// The first collection completes before the deadline
+	metrics := gather()
+	if stale := metrics[`zfs_scrape_collector_stale`].GetGauge().GetValue(); stale != 0 {
+		t.Errorf("expected fresh data, got stale=%v", stale)
+	}
+	if age := metrics[`zfs_scrape_collector_cache_age_seconds`].GetGauge().GetValue(); age != 0 {
+		t.Errorf("expected zero cache age, got %v", age)
+	}
+	lastSuccess := metrics[`zfs_scrape_collector_last_success_timestamp_seconds`].GetGauge().GetValue()
+	if lastSuccess == 0 {
+		t.Error(`expected last success timestamp to be set`)
+	}
+	if metrics[`zfs_pool_health`].TimestampMs != nil {
+		t.Error(`expected fresh metric without timestamp`)
+	}
+
+	// The second collection exceeds the deadline, and is served from the cache
+	clock.Add(10)
+	collector.deadline = time.Millisecond
+	metrics = gather()
+	close(release)
+	waitIdle(collector, `pool`)
+	if stale := metrics[`zfs_scrape_collector_stale`].GetGauge().GetValue(); stale != 1 {
+		t.Errorf("expected stale data, got stale=%v", stale)
+	}
+	if age := metrics[`zfs_scrape_collector_cache_age_seconds`].GetGauge().GetValue(); age != 10 {
+		t.Errorf("expected cache age of 10, got %v", age)
+	}
+	if v := metrics[`zfs_scrape_collector_last_success_timestamp_seconds`].GetGauge().GetValue(); v != lastSuccess {
+		t.Errorf("expected last success timestamp %v, got %v", lastSuccess, v)
+	}
+	if metrics[`zfs_pool_health`].TimestampMs == nil {
+		t.Error(`expected cached metric with timestamp`)
+	}
+}
+
+// waitIdle waits for the in-flight run of the named collector to complete
+func waitIdle(c *ZFS, name string) {
+	for {
+		c.statusMu.Lock()
+		running := c.collectorStatus(name).running
+		c.statusMu.Unlock()
+		if running == nil {
+			return
+		}
+		<-running
+	}
+}
+
+func TestZFSCollectSchedule(t *testing.T) {
+	const result = `# HELP zfs_scrape_collector_stale zfs_exporter: Whether the collector data served by the scrape was served from the cache, as the collector did not complete before the deadline.
+# TYPE zfs_scrape_collector_stale gauge
+zfs_scrape_collector_stale{collector="arc"} 0
+zfs_scrape_collector_stale{collector="pool"} 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	// The arc collector is only due on the first scrape, and the pool collector is still running on the second
+	zfsClient.EXPECT().PoolNames(gomock.Any()).Return([]string{`testpool`}, nil).Times(1)
+	zfsClient.EXPECT().ARCStats(gomock.Any()).Return(map[string]string{`hits`: `1`}, nil).Times(1)
+	release := make(chan struct{})
+	zfsPool := mock_zfs.NewMockPool(ctrl)
+	zfsPool.EXPECT().Properties(gomock.Any(), `health`).DoAndReturn(func(context.Context, ...string) (zfs.PoolProperties, error) {
+		<-release
+		return nil, errors.New(`released`)
+	}).Times(1)
+	zfsClient.EXPECT().Pool(`testpool`).Return(zfsPool).Times(1)
+
+	config := defaultConfig(zfsClient)
+	config.DisableMetrics = false
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`arc`: {
+			Name:       "arc",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`hits`),
+			Options:    map[string]*string{intervalOption: stringPointer(`1h`)},
+			factory:    newARCCollector,
+		},
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`health`),
+			Options:    map[string]*string{deadlineOption: stringPointer(`20ms`)},
+			factory:    newPoolCollector,
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = callCollector(ctx, collector, []byte(result), []string{`zfs_scrape_collector_stale`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(release)
+	waitIdle(collector, `pool`)
+}
+
+func TestZFSCollectHungPools(t *testing.T) {
+	const result = `# HELP zfs_scrape_collector_stale zfs_exporter: Whether the collector data served by the scrape was served from the cache, as the collector did not complete before the deadline.
+# TYPE zfs_scrape_collector_stale gauge
+zfs_scrape_collector_stale{collector="pool"} 1
+`
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+	// Pool discovery is bound by the deadline of the collectors waiting on it
+	release := make(chan struct{})
+	zfsClient.EXPECT().PoolNames(gomock.Any()).DoAndReturn(func(context.Context) ([]string, error) {
+		<-release
+		return nil, errors.New(`released`)
+	}).Times(1)
+
+	config := defaultConfig(zfsClient)
+	config.DisableMetrics = false
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`health`),
+			Options:    map[string]*string{deadlineOption: stringPointer(`20ms`)},
+			factory:    newPoolCollector,
+		},
+	}
+
+	if err = callCollector(ctx, collector, []byte(result), []string{`zfs_scrape_collector_stale`}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	waitIdle(collector, `pool`)
+}
+
+func TestZFSCollectInstanceError(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	zfsClient := mock_zfs.NewMockClient(ctrl)
+
+	config := defaultConfig(zfsClient)
+	collector, err := NewZFS(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector.Collectors = map[string]State{
+		`pool`: {
+			Name:       "pool",
+			Enabled:    boolPointer(true),
+			Properties: stringPointer(`health`),
+			factory: func(*slog.Logger, zfs.Client, []string, map[string]string) (Collector, error) {
+				return nil, errors.New(`failed`)
+			},
+		},
+	}
+
+	if err = callCollector(ctx, collector, nil, []string{`zfs_pool_health`}); err != nil {
+		t.Fatal(err)
+	}
+	collector.statusMu.Lock()
+	defer collector.statusMu.Unlock()
+	if collector.collectorStatus(`pool`).running != nil {
+		t.Error(`expected collector not to be left running`)
+	}
+}
+
+func TestZFSInvalidSchedule(t *testing.T) {
+	for _, option := range []string{deadlineOption, intervalOption} {
+		_, err := NewZFS(ZFSConfig{
+			Logger: logger,
+			Collectors: map[string]State{
+				`pool`: {
+					Name:       "pool",
+					Enabled:    boolPointer(true),
+					Properties: stringPointer(`health`),
+					Options:    map[string]*string{option: stringPointer(`soon`)},
+					factory:    newPoolCollector,
+				},
+			},
+		})
+		if err == nil {
+			t.Errorf("expected error for invalid %s, got nil", option)
+		}
+	}
+}
+
+func TestDatasetRoots(t *testing.T) {
+	roots, err := newDatasetRoots([]string{`tank/vm/a`, `tank/vm/`, `tank/vmstore`, `rpool`, `rpool/home`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (datasetRoots{`rpool`, `tank/vm`, `tank/vmstore`}); !reflect.DeepEqual(roots, expected) {
+		t.Fatalf("unexpected roots: %v, expected %v", roots, expected)
+	}
+	if result := roots.pool(`tank`); !reflect.DeepEqual(result, []string{`tank/vm`, `tank/vmstore`}) {
+		t.Errorf("unexpected roots for pool tank: %v", result)
+	}
+	if result := roots.pool(`backup`); len(result) != 0 {
+		t.Errorf("unexpected roots for pool backup: %v", result)
+	}
+	if result := datasetRoots(nil).pool(`tank`); !reflect.DeepEqual(result, []string{`tank`}) {
+		t.Errorf("unexpected roots for unconfigured pool: %v", result)
+	}
+
+	for _, name := range []string{``, `/tank`, `tank@snap`, `tank#bookmark`} {
+		if _, err := newDatasetRoots([]string{name}); err == nil {
+			t.Errorf("expected error for root %q, got nil", name)
+		}
+	}
+}
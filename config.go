@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pdf/zfs_exporter/v2/collector"
+	"github.com/pdf/zfs_exporter/v2/zfs"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"go.yaml.in/yaml/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+// fileConfig is the format of the YAML configuration file
+type fileConfig struct {
+	Deadline     *model.Duration                `yaml:"deadline"`
+	Timeout      *model.Duration                `yaml:"timeout"`
+	PollInterval *model.Duration                `yaml:"poll_interval"`
+	Pools        []string                       `yaml:"pools"`
+	Excludes     []string                       `yaml:"excludes"`
+	DatasetRoots []string                       `yaml:"dataset_roots"`
+	UserProps    []string                       `yaml:"dataset_user_properties"`
+	Collectors   map[string]fileCollectorConfig `yaml:"collectors"`
+}
+
+type fileCollectorConfig struct {
+	Enabled    *bool             `yaml:"enabled"`
+	Properties []string          `yaml:"properties"`
+	Options    map[string]string `yaml:"options"`
+}
+
+func loadConfigFile(path string) (*fileConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &fileConfig{}
+	if err = yaml.UnmarshalStrict(b, config); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file '%s': %w", path, err)
+	}
+	return config, nil
+}
+
+// flagConfig holds configuration from command-line flags, which take precedence over the configuration file when set
+type flagConfig struct {
+	deadline        *time.Duration
+	deadlineSet     bool
+	timeout         *time.Duration
+	timeoutSet      bool
+	pollInterval    *time.Duration
+	pollIntervalSet bool
+	pools           *[]string
+	poolsSet        bool
+	excludes        *[]string
+	excludesSet     bool
+	datasetRoots    *[]string
+	datasetRootsSet bool
+	userProps       *[]string
+	userPropsSet    bool
+	disableMetrics  bool
+	cacheTimes      bool
+}
+
+// configLoader builds the ZFS collector from flags and the configuration file, and replaces the registered collector
+// upon reload.
+type configLoader struct {
+	path       string
+	flags      *flagConfig
+	client     zfs.Client
+	logger     *slog.Logger
+	registerer prometheus.Registerer
+	current    *collector.ZFS
+	// config is the validated configuration of the current collector, used to build collectors for probe targets
+	config collector.ZFSConfig
+	// newTargetClient instantiates the ZFS client for a probe target, bounding the detection of the backend by the
+	// timeout
+	newTargetClient func(target string, timeout time.Duration) (zfs.Client, error)
+	// probeTargets holds the hosts that may be probed, probing is disabled if empty
+	probeTargets map[string]struct{}
+	// targets holds the collectors for probe targets, discarded upon reload
+	targets map[string]*collector.ZFS
+	// creating shares the creation of the collector for a probe target between concurrent probes
+	creating singleflight.Group
+	mu       sync.Mutex
+}
+
+func (l *configLoader) build() (*collector.ZFS, collector.ZFSConfig, error) {
+	file := &fileConfig{}
+	if l.path != `` {
+		var err error
+		if file, err = loadConfigFile(l.path); err != nil {
+			return nil, collector.ZFSConfig{}, err
+		}
+	}
+
+	collectorConfigs := make(map[string]collector.CollectorConfig, len(file.Collectors))
+	for name, c := range file.Collectors {
+		collectorConfigs[name] = collector.CollectorConfig{
+			Enabled:    c.Enabled,
+			Properties: c.Properties,
+			Options:    c.Options,
+		}
+	}
+	collectors, err := collector.ConfigureCollectors(collectorConfigs)
+	if err != nil {
+		return nil, collector.ZFSConfig{}, err
+	}
+
+	deadline := *l.flags.deadline
+	if !l.flags.deadlineSet && file.Deadline != nil {
+		deadline = time.Duration(*file.Deadline)
+	}
+	timeout := *l.flags.timeout
+	if !l.flags.timeoutSet && file.Timeout != nil {
+		timeout = time.Duration(*file.Timeout)
+	}
+	pollInterval := *l.flags.pollInterval
+	if !l.flags.pollIntervalSet && file.PollInterval != nil {
+		pollInterval = time.Duration(*file.PollInterval)
+	}
+	pools := *l.flags.pools
+	if !l.flags.poolsSet && file.Pools != nil {
+		pools = file.Pools
+	}
+	excludes := *l.flags.excludes
+	if !l.flags.excludesSet && file.Excludes != nil {
+		excludes = file.Excludes
+	}
+	datasetRoots := *l.flags.datasetRoots
+	if !l.flags.datasetRootsSet && file.DatasetRoots != nil {
+		datasetRoots = file.DatasetRoots
+	}
+	userProps := *l.flags.userProps
+	if !l.flags.userPropsSet && file.UserProps != nil {
+		userProps = file.UserProps
+	}
+
+	config := collector.ZFSConfig{
+		DisableMetrics:  l.flags.disableMetrics,
+		CacheTimestamps: l.flags.cacheTimes,
+		Deadline:        deadline,
+		Timeout:         timeout,
+		PollInterval:    pollInterval,
+		Pools:           pools,
+		Excludes:        excludes,
+		DatasetRoots:    datasetRoots,
+		UserProperties:  userProps,
+		Logger:          l.logger,
+		ZFSClient:       l.client,
+		Collectors:      collectors,
+	}
+	c, err := collector.NewZFS(config)
+	if err != nil {
+		return nil, collector.ZFSConfig{}, err
+	}
+
+	// Ensure the metric descriptors are consistent before replacing the running collector
+	if err = prometheus.NewPedanticRegistry().Register(c); err != nil {
+		return nil, collector.ZFSConfig{}, fmt.Errorf("invalid collector configuration: %w", err)
+	}
+
+	return c, config, nil
+}
+
+// reload builds a new ZFS collector and replaces the registered collector. If the configuration is invalid, the
+// running collector is retained.
+func (l *configLoader) reload() error {
+	c, config, err := l.build()
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current != nil {
+		l.registerer.Unregister(l.current)
+	}
+	if err = l.registerer.Register(c); err != nil {
+		if l.current != nil {
+			l.registerer.MustRegister(l.current)
+		}
+		return err
+	}
+	if l.current != nil {
+		l.current.Stop()
+	}
+	for _, target := range l.targets {
+		target.Stop()
+	}
+	c.Start()
+	l.current = c
+	l.config = config
+	l.targets = make(map[string]*collector.ZFS)
+
+	if len(c.Pools) > 0 {
+		l.logger.Info("Enabling pools", "pools", strings.Join(c.Pools, ", "))
+	} else {
+		l.logger.Info("Enabling pools", "pools", "(all)")
+	}
+
+	collectorNames := make([]string, 0, len(c.Collectors))
+	for n, c := range c.Collectors {
+		if *c.Enabled {
+			collectorNames = append(collectorNames, n)
+		}
+	}
+	sort.Strings(collectorNames)
+	l.logger.Info("Enabling collectors", "collectors", strings.Join(collectorNames, ", "))
+
+	return nil
+}
+
+// ServeHTTP implements the http.Handler interface, reloading the configuration upon POST requests
+func (l *configLoader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set(`Allow`, http.MethodPost)
+		http.Error(w, `This endpoint requires a POST request.`, http.StatusMethodNotAllowed)
+		return
+	}
+	if err := l.reload(); err != nil {
+		l.logger.Error("Error reloading configuration", "err", err)
+		http.Error(w, fmt.Sprintf("failed to reload configuration: %s", err), http.StatusInternalServerError)
+		return
+	}
+	l.logger.Info("Reloaded configuration")
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestLoader(t *testing.T, config string, flags *flagConfig) *configLoader {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), `config.yml`)
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if flags == nil {
+		flags = &flagConfig{}
+	}
+	if flags.deadline == nil {
+		deadline := 8 * time.Second
+		flags.deadline = &deadline
+	}
+	if flags.timeout == nil {
+		var timeout time.Duration
+		flags.timeout = &timeout
+	}
+	if flags.pollInterval == nil {
+		var pollInterval time.Duration
+		flags.pollInterval = &pollInterval
+	}
+	if flags.pools == nil {
+		flags.pools = &[]string{}
+	}
+	if flags.excludes == nil {
+		flags.excludes = &[]string{}
+	}
+	if flags.datasetRoots == nil {
+		flags.datasetRoots = &[]string{}
+	}
+	if flags.userProps == nil {
+		flags.userProps = &[]string{}
+	}
+	return &configLoader{
+		path:       path,
+		flags:      flags,
+		client:     mock_zfs.NewMockClient(gomock.NewController(t)),
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		registerer: prometheus.NewRegistry(),
+	}
+}
+
+func TestConfigLoaderReload(t *testing.T) {
+	const config = `
+deadline: 30s
+pools: [tank]
+excludes: ['^tank/docker/']
+dataset_roots: [tank/vm]
+dataset_user_properties: ['com.example:owner']
+collectors:
+  pool:
+    enabled: true
+    properties: [health, size]
+  snapshot-summary:
+    enabled: true
+    options:
+      group: '_(hourly|daily)$'
+`
+	loader := newTestLoader(t, config, nil)
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if pools := loader.current.Pools; len(pools) != 1 || pools[0] != `tank` {
+		t.Errorf("unexpected pools: %v", pools)
+	}
+	if state := loader.current.Collectors[`pool`]; !*state.Enabled || *state.Properties != `health,size` {
+		t.Errorf("unexpected pool collector state: enabled=%t properties=%s", *state.Enabled, *state.Properties)
+	}
+}
+
+func TestConfigLoaderFlagsOverride(t *testing.T) {
+	pools := []string{`backup`}
+	loader := newTestLoader(t, "pools: [tank]\n", &flagConfig{pools: &pools, poolsSet: true})
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if pools := loader.current.Pools; len(pools) != 1 || pools[0] != `backup` {
+		t.Errorf("unexpected pools: %v", pools)
+	}
+}
+
+func TestConfigLoaderInvalid(t *testing.T) {
+	testCases := map[string]string{
+		`unknown field`:         "unknown: true\n",
+		`unknown collector`:     "collectors:\n  nonexistent:\n    enabled: true\n",
+		`unknown option`:        "collectors:\n  pool:\n    options:\n      nonexistent: x\n",
+		`invalid exclude`:       "excludes: ['(']\n",
+		`invalid root`:          "dataset_roots: ['tank@snap']\n",
+		`invalid option`:        "collectors:\n  snapshot-summary:\n    enabled: true\n    options:\n      group: '('\n",
+		`invalid user property`: "dataset_user_properties: [owner]\ncollectors:\n  dataset-filesystem:\n    enabled: true\n",
+	}
+
+	for name, config := range testCases {
+		config := config
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			loader := newTestLoader(t, "pools: [tank]\n", nil)
+			if err := loader.reload(); err != nil {
+				t.Fatal(err)
+			}
+			current := loader.current
+
+			if err := os.WriteFile(loader.path, []byte(config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			loader.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, `/-/reload`, nil))
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("unexpected status code: %d", rec.Code)
+			}
+			if loader.current != current {
+				t.Error(`expected running collector to be retained`)
+			}
+		})
+	}
+}
+
+func TestConfigLoaderMethod(t *testing.T) {
+	loader := newTestLoader(t, ``, nil)
+	rec := httptest.NewRecorder()
+	loader.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, `/-/reload`, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+}
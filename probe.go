@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/pdf/zfs_exporter/v2/collector"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var errTargetNotPermitted = errors.New(`target is not permitted, see --probe.target`)
+
+// target returns the collector for the probe target, creating it with the current configuration if necessary. The
+// collector, and its cache, is retained until the next reload. Only the configured probe targets are permitted, so
+// that the number of collectors, and the hosts contacted, are bounded.
+func (l *configLoader) target(name string) (*collector.ZFS, error) {
+	if _, ok := l.probeTargets[name]; !ok {
+		return nil, errTargetNotPermitted
+	}
+
+	l.mu.Lock()
+	c, ok := l.targets[name]
+	l.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	// The client may need to contact the target, so other probes are not blocked while it is created, and concurrent
+	// probes of the target share its creation. Failures are not retained, such that the next probe tries again.
+	v, err, _ := l.creating.Do(name, func() (any, error) {
+		l.mu.Lock()
+		c, ok := l.targets[name]
+		config := l.config
+		l.mu.Unlock()
+		if ok {
+			return c, nil
+		}
+
+		client, err := l.newTargetClient(name, config.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		config.ZFSClient = client
+		if c, err = collector.NewZFS(config); err != nil {
+			return nil, err
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.targets[name] = c
+		c.Start()
+
+		return c, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*collector.ZFS), nil
+}
+
+// probeHandler serves metrics for the host named by the `target` query parameter
+type probeHandler struct {
+	loader *configLoader
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h probeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get(`target`)
+	if target == `` {
+		http.Error(w, `'target' parameter must be specified`, http.StatusBadRequest)
+		return
+	}
+	c, err := h.loader.target(target)
+	if errors.Is(err, errTargetNotPermitted) {
+		http.Error(w, fmt.Sprintf("failed to probe target '%s': %s", target, err), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		h.loader.logger.Error("Error creating collector for probe target", "target", target, "err", err)
+		http.Error(w, fmt.Sprintf("failed to probe target '%s': %s", target, err), http.StatusInternalServerError)
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
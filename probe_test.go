@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pdf/zfs_exporter/v2/zfs"
+	"github.com/pdf/zfs_exporter/v2/zfs/mock_zfs"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProbe(t *testing.T) {
+	const config = `
+collectors:
+  pool:
+    enabled: true
+    properties: [health]
+`
+	loader := newTestLoader(t, config, nil)
+	loader.probeTargets = map[string]struct{}{`tank`: {}, `backup`: {}}
+	ctrl := gomock.NewController(t)
+	clients := make(map[string]int)
+	loader.newTargetClient = func(target string, _ time.Duration) (zfs.Client, error) {
+		clients[target]++
+		poolProperties := mock_zfs.NewMockPoolProperties(ctrl)
+		poolProperties.EXPECT().Properties().Return(map[string]string{`health`: `ONLINE`}).AnyTimes()
+		pool := mock_zfs.NewMockPool(ctrl)
+		pool.EXPECT().Properties(gomock.Any(), `health`).Return(poolProperties, nil).AnyTimes()
+		client := mock_zfs.NewMockClient(ctrl)
+		client.EXPECT().PoolNames(gomock.Any()).Return([]string{target}, nil).AnyTimes()
+		client.EXPECT().Pool(target).Return(pool).AnyTimes()
+		return client, nil
+	}
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	handler := probeHandler{loader: loader}
+
+	for _, target := range []string{`tank`, `backup`, `tank`} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, `/probe?target=`+target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status code: %d", rec.Code)
+		}
+		if expected := `zfs_pool_health{pool="` + target + `"} 0`; !strings.Contains(rec.Body.String(), expected) {
+			t.Errorf("expected %q in response:\n%s", expected, rec.Body.String())
+		}
+	}
+	if clients[`tank`] != 1 || clients[`backup`] != 1 {
+		t.Errorf("expected one client per target, got %v", clients)
+	}
+
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, `/probe?target=tank`, nil))
+	if clients[`tank`] != 2 {
+		t.Errorf("expected target to be recreated after reload, got %d clients", clients[`tank`])
+	}
+}
+
+func TestProbeMissingTarget(t *testing.T) {
+	loader := newTestLoader(t, ``, nil)
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	probeHandler{loader: loader}.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, `/probe`, nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+}
+
+func TestProbeTargetNotPermitted(t *testing.T) {
+	loader := newTestLoader(t, ``, nil)
+	loader.probeTargets = map[string]struct{}{`tank`: {}}
+	loader.newTargetClient = func(target string, _ time.Duration) (zfs.Client, error) {
+		t.Fatalf("unexpected client created for target: %s", target)
+		return nil, nil
+	}
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	probeHandler{loader: loader}.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, `/probe?target=backup`, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code: %d", rec.Code)
+	}
+	if len(loader.targets) != 0 {
+		t.Errorf("expected no targets, got %d", len(loader.targets))
+	}
+}
+
+func TestProbeConcurrentCreation(t *testing.T) {
+	loader := newTestLoader(t, ``, nil)
+	loader.probeTargets = map[string]struct{}{`tank`: {}}
+	const probes = 4
+	var created, started atomic.Int32
+	loader.newTargetClient = func(target string, _ time.Duration) (zfs.Client, error) {
+		// The first attempt fails once all probes have started, as for an unreachable host, and is retried by the next
+		// probe
+		if created.Add(1) == 1 {
+			for started.Load() < probes {
+				runtime.Gosched()
+			}
+			return nil, errors.New(`unreachable`)
+		}
+		return mock_zfs.NewMockClient(gomock.NewController(t)), nil
+	}
+	if err := loader.reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, probes)
+	for i := 0; i < probes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Add(1)
+			_, err := loader.target(`tank`)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err == nil {
+			t.Error(`expected creation error, got nil`)
+		}
+	}
+	if n := created.Load(); n != 1 {
+		t.Errorf("expected a single client to be created by concurrent probes, got %d", n)
+	}
+
+	if _, err := loader.target(`tank`); err != nil {
+		t.Fatalf("expected creation to be retried, got %v", err)
+	}
+}
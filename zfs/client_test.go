@@ -0,0 +1,275 @@
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var backendFixtures = map[Backend]ReplayRunner{
+	BackendText: {
+		`zpool list -Ho name`: `testdata/text/zpool-list.txt`,
+		`zpool get -Hpo name,property,value allocated,health,fragmentation tank`:         `testdata/text/zpool-get.txt`,
+		`zpool get -Hpo name,property,value all tank`:                                    `testdata/text/zpool-get-all.txt`,
+		`zfs get -Hprt filesystem -o name,property,value used,available tank`:            `testdata/text/zfs-get-filesystem.txt`,
+		`zfs get -Hprt snapshot -o name,property,value used tank`:                        `testdata/text/zfs-get-snapshot.txt`,
+		`zfs get -Hprt bookmark -o name,property,value creation tank`:                    `testdata/text/zfs-get-bookmark.txt`,
+		`zfs get -Hprt filesystem -o name,property,value -d 0 used,available tank/home`:  `testdata/text/zfs-get-filesystem-root.txt`,
+		`zpool get -Hpo name,property,value allocated,health,fragmentation,comment tank`: `testdata/text/zpool-get-comment.txt`,
+		`zfs userspace -Hp -o name,used,quota,objused,objquota tank/home`:                `testdata/text/zfs-userspace.txt`,
+		`zfs holds -Hp tank/home@daily-1 tank/home@daily-2`:                              `testdata/text/zfs-holds.txt`,
+	},
+	BackendJSON: {
+		`zfs version -j`:        `testdata/json/zfs-version.json`,
+		`zpool list -j -o name`: `testdata/json/zpool-list.json`,
+		`zpool get -jp allocated,health,fragmentation tank`:         `testdata/json/zpool-get.json`,
+		`zpool get -jp all tank`:                                    `testdata/json/zpool-get-all.json`,
+		`zfs get -jprt filesystem used,available tank`:              `testdata/json/zfs-get-filesystem.json`,
+		`zfs get -jprt snapshot used tank`:                          `testdata/json/zfs-get-snapshot.json`,
+		`zfs get -jprt bookmark creation tank`:                      `testdata/json/zfs-get-bookmark.json`,
+		`zfs get -jprt filesystem -d 0 used,available tank/home`:    `testdata/json/zfs-get-filesystem-root.json`,
+		`zpool get -jp allocated,health,fragmentation,comment tank`: `testdata/json/zpool-get-comment.json`,
+		// Space accounting and holds have no JSON output, and is parsed as text by both backends
+		`zfs userspace -Hp -o name,used,quota,objused,objquota tank/home`: `testdata/text/zfs-userspace.txt`,
+		`zfs holds -Hp tank/home@daily-1 tank/home@daily-2`:               `testdata/text/zfs-holds.txt`,
+	},
+}
+
+func newFixtureClient(t *testing.T, backend Backend) Client {
+	t.Helper()
+	client, err := newClient(clientImpl{runner: backendFixtures[backend]}, BackendAuto, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, isJSON := client.(jsonClientImpl)
+	if isJSON != (backend == BackendJSON) {
+		t.Fatalf("unexpected client selected for backend %s: %T", backend, client)
+	}
+	return client
+}
+
+func datasetResults(datasets []DatasetProperties) map[string]map[string]string {
+	result := make(map[string]map[string]string, len(datasets))
+	for _, d := range datasets {
+		result[d.DatasetName()] = d.Properties()
+	}
+	return result
+}
+
+func TestClientBackends(t *testing.T) {
+	testCases := []struct {
+		name     string
+		call     func(Client) (any, error)
+		expected any
+		wantErr  bool
+	}{
+		{
+			name: `pool names`,
+			call: func(c Client) (any, error) {
+				return c.PoolNames(context.Background())
+			},
+			expected: []string{`backup`, `tank`},
+		},
+		{
+			name: `pool properties`,
+			call: func(c Client) (any, error) {
+				props, err := c.Pool(`tank`).Properties(context.Background(), `allocated`, `health`, `fragmentation`)
+				if err != nil {
+					return nil, err
+				}
+				return props.Properties(), nil
+			},
+			expected: map[string]string{
+				`allocated`:     `3221225472`,
+				`health`:        `ONLINE`,
+				`fragmentation`: `4`,
+			},
+		},
+		{
+			name: `pool properties by pattern`,
+			call: func(c Client) (any, error) {
+				props, err := c.Pool(`tank`).Properties(context.Background(), `allocated`, `feature@*`)
+				if err != nil {
+					return nil, err
+				}
+				return props.Properties(), nil
+			},
+			expected: map[string]string{
+				`allocated`:               `3221225472`,
+				`feature@async_destroy`:   `enabled`,
+				`feature@block_cloning`:   `active`,
+				`feature@raidz_expansion`: `disabled`,
+			},
+		},
+		{
+			name: `pool properties error`,
+			call: func(c Client) (any, error) {
+				return c.Pool(`nonexistent`).Properties(context.Background(), `allocated`, `health`, `fragmentation`)
+			},
+			wantErr: true,
+		},
+		{
+			name: `filesystem properties`,
+			call: func(c Client) (any, error) {
+				datasets, err := c.Datasets(`tank`, DatasetFilesystem).Properties(context.Background(), `used`, `available`)
+				if err != nil {
+					return nil, err
+				}
+				return datasetResults(datasets), nil
+			},
+			expected: map[string]map[string]string{
+				`tank`: {
+					`used`:      `3221225472`,
+					`available`: `7516192768`,
+				},
+				`tank/home`: {
+					`used`:      `1073741824`,
+					`available`: `7516192768`,
+				},
+			},
+		},
+		{
+			name: `filesystem properties by root`,
+			call: func(c Client) (any, error) {
+				datasets, err := c.Datasets(`tank`, DatasetFilesystem).Root(`tank/home`, 0).Properties(context.Background(), `used`, `available`)
+				if err != nil {
+					return nil, err
+				}
+				return datasetResults(datasets), nil
+			},
+			expected: map[string]map[string]string{
+				`tank/home`: {
+					`used`:      `1073741824`,
+					`available`: `7516192768`,
+				},
+			},
+		},
+		{
+			name: `snapshot properties`,
+			call: func(c Client) (any, error) {
+				datasets, err := c.Datasets(`tank`, DatasetSnapshot).Properties(context.Background(), `used`)
+				if err != nil {
+					return nil, err
+				}
+				return datasetResults(datasets), nil
+			},
+			expected: map[string]map[string]string{
+				`tank/home@daily-1`: {
+					`used`: `65536`,
+				},
+				`tank/home@daily-2`: {
+					`used`: `131072`,
+				},
+			},
+		},
+		{
+			name: `bookmark properties`,
+			call: func(c Client) (any, error) {
+				datasets, err := c.Datasets(`tank`, DatasetBookmark).Properties(context.Background(), `creation`)
+				if err != nil {
+					return nil, err
+				}
+				return datasetResults(datasets), nil
+			},
+			expected: map[string]map[string]string{
+				`tank/home#repl-1`: {
+					`creation`: `1700000000`,
+				},
+				`tank/home#repl-2`: {
+					`creation`: `1700086400`,
+				},
+			},
+		},
+		{
+			name: `user space usage`,
+			call: func(c Client) (any, error) {
+				return c.SpaceUsage(context.Background(), `tank/home`, SpaceUser, `used`, `quota`, `objused`, `objquota`)
+			},
+			expected: []SpaceUsage{
+				{Name: `alice`, Properties: map[string]string{`used`: `805306368`, `quota`: `1073741824`, `objused`: `1520`, `objquota`: `none`}},
+				{Name: `bob`, Properties: map[string]string{`used`: `268435456`, `quota`: `none`, `objused`: `312`, `objquota`: `10000`}},
+				{Name: `root`, Properties: map[string]string{`used`: `512`, `quota`: `none`, `objused`: `7`, `objquota`: `none`}},
+			},
+		},
+		{
+			name: `space usage error`,
+			call: func(c Client) (any, error) {
+				return c.SpaceUsage(context.Background(), `nonexistent`, SpaceGroup, `used`)
+			},
+			wantErr: true,
+		},
+		{
+			name: `snapshot holds`,
+			call: func(c Client) (any, error) {
+				return c.Holds(context.Background(), `tank/home@daily-1`, `tank/home@daily-2`)
+			},
+			expected: []Hold{
+				{Snapshot: `tank/home@daily-1`, Tag: `backup-job`, Timestamp: 1735689600},
+				{Snapshot: `tank/home@daily-1`, Tag: `keep`, Timestamp: 1735693200},
+				{Snapshot: `tank/home@daily-2`, Tag: `backup-job`, Timestamp: 1735776000},
+			},
+		},
+		{
+			name: `snapshot holds error`,
+			call: func(c Client) (any, error) {
+				return c.Holds(context.Background(), `nonexistent@daily-1`)
+			},
+			wantErr: true,
+		},
+		{
+			name: `dataset properties error`,
+			call: func(c Client) (any, error) {
+				return c.Datasets(`nonexistent`, DatasetFilesystem).Properties(context.Background(), `used`, `available`)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, backend := range []Backend{BackendText, BackendJSON} {
+		for _, tc := range testCases {
+			tc := tc
+			backend := backend
+			t.Run(fmt.Sprintf("%s/%s", backend, tc.name), func(t *testing.T) {
+				t.Parallel()
+				result, err := tc.call(newFixtureClient(t, backend))
+				if tc.wantErr {
+					if err == nil {
+						t.Fatal(`expected error, got nil`)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(result, tc.expected) {
+					t.Fatalf("unexpected result:\n%+v\nexpected:\n%+v", result, tc.expected)
+				}
+			})
+		}
+	}
+}
+
+func TestJSONValueWithTab(t *testing.T) {
+	client := newFixtureClient(t, BackendJSON)
+	props, err := client.Pool(`tank`).Properties(context.Background(), `allocated`, `health`, `fragmentation`, `comment`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := props.Properties()[`comment`]; v != "primary\tstorage" {
+		t.Fatalf("unexpected comment value: %q", v)
+	}
+}
+
+func TestTextValueWithTab(t *testing.T) {
+	client := newFixtureClient(t, BackendText)
+	if _, err := client.Pool(`tank`).Properties(context.Background(), `allocated`, `health`, `fragmentation`, `comment`); err == nil {
+		t.Fatal(`expected error, got nil`)
+	}
+}
+
+func TestUnsupportedBackend(t *testing.T) {
+	if _, err := New(Config{Backend: `xml`}); err == nil {
+		t.Fatal(`expected error, got nil`)
+	}
+}
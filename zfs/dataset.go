@@ -1,6 +1,8 @@
 package zfs
 
 import (
+	"context"
+	"strconv"
 	"strings"
 )
 
@@ -14,11 +16,16 @@ const (
 	DatasetVolume DatasetKind = `volume`
 	// DatasetSnapshot enum entry
 	DatasetSnapshot DatasetKind = `snapshot`
+	// DatasetBookmark enum entry
+	DatasetBookmark DatasetKind = `bookmark`
 )
 
 type datasetsImpl struct {
-	pool string
-	kind DatasetKind
+	pool   string
+	kind   DatasetKind
+	root   string
+	depth  int
+	runner Runner
 }
 
 func (d datasetsImpl) Pool() string {
@@ -29,12 +36,36 @@ func (d datasetsImpl) Kind() DatasetKind {
 	return d.kind
 }
 
-func (d datasetsImpl) Properties(props ...string) ([]DatasetProperties, error) {
+func (d datasetsImpl) Root(name string, depth int) Datasets {
+	return d.withRoot(name, depth)
+}
+
+func (d datasetsImpl) Properties(ctx context.Context, props ...string) ([]DatasetProperties, error) {
 	handler := newDatasetHandler()
-	if err := execute(d.pool, handler, `zfs`, `get`, `-Hprt`, string(d.kind), `-o`, `name,property,value`, strings.Join(props, `,`)); err != nil {
+	selector := newPropertySelector(props)
+	args := append([]string{`get`, `-Hprt`, string(d.kind), `-o`, `name,property,value`}, d.depthArgs()...)
+	if err := execute(ctx, d.runner, d.root, handler, `zfs`, append(args, selector.request())...); err != nil {
 		return nil, err
 	}
-	return handler.datasets(), nil
+	result := handler.datasets()
+	for _, dataset := range result {
+		selector.filter(dataset.Properties())
+	}
+	return result, nil
+}
+
+func (d datasetsImpl) withRoot(name string, depth int) datasetsImpl {
+	d.root = name
+	d.depth = depth
+	return d
+}
+
+// depthArgs returns the arguments limiting the recursion depth of `zfs get`, if any
+func (d datasetsImpl) depthArgs() []string {
+	if d.depth < 0 {
+		return nil
+	}
+	return []string{`-d`, strconv.Itoa(d.depth)}
 }
 
 type datasetPropertiesImpl struct {
@@ -84,10 +115,13 @@ func newDatasetPropertiesImpl(name string) *datasetPropertiesImpl {
 	}
 }
 
-func newDatasetsImpl(pool string, kind DatasetKind) datasetsImpl {
+func newDatasetsImpl(pool string, kind DatasetKind, runner Runner) datasetsImpl {
 	return datasetsImpl{
-		pool: pool,
-		kind: kind,
+		pool:   pool,
+		kind:   kind,
+		root:   pool,
+		depth:  -1,
+		runner: runner,
 	}
 }
 
@@ -0,0 +1,54 @@
+package zfs
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// holdsBatchSize limits the number of snapshots passed to each invocation of `zfs holds`
+const holdsBatchSize = 256
+
+// Hold contains a user hold on a snapshot, as reported by `zfs holds`. The timestamp is in seconds since the epoch.
+type Hold struct {
+	Snapshot  string
+	Tag       string
+	Timestamp uint64
+}
+
+// holds returns the user holds on the named snapshots
+func holds(ctx context.Context, runner Runner, snapshots ...string) ([]Hold, error) {
+	var result []Hold
+	for len(snapshots) > 0 {
+		batch := snapshots[:min(len(snapshots), holdsBatchSize)]
+		snapshots = snapshots[len(batch):]
+		err := run(ctx, runner, func(out io.Reader) error {
+			r := csv.NewReader(out)
+			r.Comma = '\t'
+			r.LazyQuotes = true
+			r.FieldsPerRecord = 3
+
+			for {
+				line, err := r.Read()
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				timestamp, err := strconv.ParseUint(line[2], 10, 64)
+				if err != nil {
+					return ErrInvalidOutput
+				}
+				result = append(result, Hold{Snapshot: line[0], Tag: line[1], Timestamp: timestamp})
+			}
+		}, `zfs`, append([]string{`holds`, `-Hp`}, batch...)...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package zfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// jsonValue decodes a JSON property value that may be represented either as a string, or as a number when
+// `--json-int` is in effect.
+type jsonValue string
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (v *jsonValue) UnmarshalJSON(b []byte) error {
+	if len(b) == 0 || b[0] != '"' {
+		*v = jsonValue(b)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*v = jsonValue(s)
+	return nil
+}
+
+type jsonProperty struct {
+	Value jsonValue `json:"value"`
+}
+
+// jsonObject is the common representation of a pool or dataset in JSON output
+type jsonObject struct {
+	Name       string                  `json:"name"`
+	Properties map[string]jsonProperty `json:"properties"`
+}
+
+func (o jsonObject) properties() map[string]string {
+	result := make(map[string]string, len(o.Properties))
+	for k, v := range o.Properties {
+		result[k] = string(v.Value)
+	}
+	return result
+}
+
+type jsonPoolOutput struct {
+	Pools map[string]jsonObject `json:"pools"`
+}
+
+type jsonDatasetOutput struct {
+	Datasets map[string]jsonObject `json:"datasets"`
+}
+
+type jsonVersionOutput struct {
+	ZFSVersion struct {
+		Userland string `json:"userland"`
+	} `json:"zfs_version"`
+}
+
+// decodeJSON returns a parse function that decodes the command output into v
+func decodeJSON(v any) func(io.Reader) error {
+	return func(r io.Reader) error {
+		if err := json.NewDecoder(r).Decode(v); err != nil {
+			return ErrInvalidOutput
+		}
+		return nil
+	}
+}
+
+// supportsJSON determines whether the installed ZFS tools support JSON output. An error is returned if the tools could
+// not be queried, ie - upon cancellation of ctx, or failure to connect to a remote host.
+func supportsJSON(ctx context.Context, runner Runner) (bool, error) {
+	var out jsonVersionOutput
+	if err := run(ctx, runner, decodeJSON(&out), `zfs`, `version`, `-j`); err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrConnection) {
+			return false, fmt.Errorf("failed to detect backend: %w", err)
+		}
+		return false, nil
+	}
+	return out.ZFSVersion.Userland != ``, nil
+}
+
+// jsonClientImpl overrides the text client with implementations that parse JSON output, where available
+type jsonClientImpl struct {
+	clientImpl
+}
+
+func (z jsonClientImpl) PoolNames(ctx context.Context) ([]string, error) {
+	var out jsonPoolOutput
+	if err := run(ctx, z.runner, decodeJSON(&out), `zpool`, `list`, `-j`, `-o`, `name`); err != nil {
+		return nil, err
+	}
+	pools := make([]string, 0, len(out.Pools))
+	for name := range out.Pools {
+		pools = append(pools, name)
+	}
+	sort.Strings(pools)
+
+	return pools, nil
+}
+
+func (z jsonClientImpl) Pool(name string) Pool {
+	return jsonPoolImpl{poolImpl: newPoolImpl(name, z.kstat, z.runner)}
+}
+
+func (z jsonClientImpl) Datasets(pool string, kind DatasetKind) Datasets {
+	return jsonDatasetsImpl{datasetsImpl: newDatasetsImpl(pool, kind, z.runner)}
+}
+
+type jsonPoolImpl struct {
+	poolImpl
+}
+
+func (p jsonPoolImpl) Properties(ctx context.Context, props ...string) (PoolProperties, error) {
+	handler := newPoolPropertiesImpl()
+	var out jsonPoolOutput
+	selector := newPropertySelector(props)
+	if err := run(ctx, p.runner, decodeJSON(&out), `zpool`, `get`, `-jp`, selector.request(), p.name); err != nil {
+		return handler, err
+	}
+	pool, ok := out.Pools[p.name]
+	if !ok {
+		return handler, ErrInvalidOutput
+	}
+	handler.properties = pool.properties()
+	selector.filter(handler.properties)
+
+	return handler, nil
+}
+
+type jsonDatasetsImpl struct {
+	datasetsImpl
+}
+
+func (d jsonDatasetsImpl) Root(name string, depth int) Datasets {
+	return jsonDatasetsImpl{datasetsImpl: d.withRoot(name, depth)}
+}
+
+func (d jsonDatasetsImpl) Properties(ctx context.Context, props ...string) ([]DatasetProperties, error) {
+	var out jsonDatasetOutput
+	selector := newPropertySelector(props)
+	args := append([]string{`get`, `-jprt`, string(d.kind)}, d.depthArgs()...)
+	if err := run(ctx, d.runner, decodeJSON(&out), `zfs`, append(args, selector.request(), d.root)...); err != nil {
+		return nil, err
+	}
+	result := make([]DatasetProperties, 0, len(out.Datasets))
+	for name, dataset := range out.Datasets {
+		if !strings.HasPrefix(name, d.root) {
+			return nil, ErrInvalidOutput
+		}
+		properties := dataset.properties()
+		selector.filter(properties)
+		result = append(result, &datasetPropertiesImpl{
+			datasetName: name,
+			properties:  properties,
+		})
+	}
+
+	return result, nil
+}
+
+func newJSONClientImpl(client clientImpl) jsonClientImpl {
+	return jsonClientImpl{clientImpl: client}
+}
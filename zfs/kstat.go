@@ -0,0 +1,105 @@
+package zfs
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultKstatRoot is the location of the ZFS kstat directory on Linux
+const DefaultKstatRoot = `/proc/spl/kstat/zfs`
+
+// kstatReader reads the named kstat file, and passes it to the parse function
+type kstatReader func(ctx context.Context, parse func(io.Reader) error, path ...string) error
+
+// localKstatReader returns a kstatReader for kstat files below root on the local host
+func localKstatReader(root string) kstatReader {
+	return func(_ context.Context, parse func(io.Reader) error, path ...string) error {
+		return readKstat(parse, root, path...)
+	}
+}
+
+// readKstat opens the named kstat file below root, and passes it to the parse function
+func readKstat(parse func(io.Reader) error, root string, path ...string) error {
+	f, err := os.Open(filepath.Join(append([]string{root}, path...)...))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return parse(f)
+}
+
+// kstatHandler parses kstat files into a map of statistic names to values
+type kstatHandler struct {
+	values map[string]string
+}
+
+// processNamed reads kstat "named" output, ie - arcstats, consisting of a header line, a column header line (name,
+// type, data), and one line per statistic.
+func (h *kstatHandler) processNamed(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case line == 1:
+			continue
+		case line == 2:
+			if len(fields) != 3 || fields[0] != `name` || fields[1] != `type` || fields[2] != `data` {
+				return ErrInvalidOutput
+			}
+			continue
+		case len(fields) == 0:
+			continue
+		case len(fields) != 3:
+			return ErrInvalidOutput
+		}
+		h.values[fields[0]] = fields[2]
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if line < 2 {
+		return ErrInvalidOutput
+	}
+
+	return nil
+}
+
+// processIO reads kstat "io" output, consisting of a header line, a line of statistic names, and a line of values.
+func (h *kstatHandler) processIO(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lines := make([][]string, 0, 3)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(lines) == cap(lines) {
+			return ErrInvalidOutput
+		}
+		lines = append(lines, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(lines) != cap(lines) || len(lines[1]) == 0 || len(lines[1]) != len(lines[2]) {
+		return ErrInvalidOutput
+	}
+	for i, name := range lines[1] {
+		h.values[name] = lines[2][i]
+	}
+
+	return nil
+}
+
+func newKstatHandler() *kstatHandler {
+	return &kstatHandler{
+		values: make(map[string]string),
+	}
+}
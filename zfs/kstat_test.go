@@ -0,0 +1,143 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestARCStats(t *testing.T) {
+	client, err := New(Config{KstatRoot: `testdata/kstat`, Backend: BackendText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := client.ARCStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		`hits`:                   `142213587`,
+		`misses`:                 `1234567`,
+		`c_max`:                  `8589934592`,
+		`l2_size`:                `0`,
+		`memory_available_bytes`: `-123456`,
+	}
+	if len(stats) != 15 {
+		t.Errorf("expected 15 statistics, got %d", len(stats))
+	}
+	for k, v := range expected {
+		if stats[k] != v {
+			t.Errorf("%s = %q, expected %q", k, stats[k], v)
+		}
+	}
+}
+
+func TestARCStatsMissing(t *testing.T) {
+	client, err := New(Config{KstatRoot: `testdata/nonexistent`, Backend: BackendText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ARCStats(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestKstatHandlerNamedInvalid(t *testing.T) {
+	for _, input := range []string{
+		``,
+		"13 1 0x01 147 39984 2870436735 1167433640374757\n",
+		"13 1 0x01 147 39984 2870436735 1167433640374757\nname type value\n",
+		"13 1 0x01 147 39984 2870436735 1167433640374757\nname type data\nhits 4\n",
+	} {
+		h := newKstatHandler()
+		if err := h.processNamed(strings.NewReader(input)); !errors.Is(err, ErrInvalidOutput) {
+			t.Errorf("expected ErrInvalidOutput for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestPoolIOStats(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pool     string
+		count    int
+		expected map[string]string
+		err      error
+	}{
+		{
+			name:  `io and iostats`,
+			pool:  `tank`,
+			count: 18,
+			expected: map[string]string{
+				`nread`:           `1884160`,
+				`nwritten`:        `3206144`,
+				`wtime`:           `9283432`,
+				`rcnt`:            `1`,
+				`arc_read_count`:  `1024`,
+				`arc_write_bytes`: `2097152`,
+			},
+		},
+		{
+			name:  `iostats only`,
+			pool:  `backup`,
+			count: 6,
+			expected: map[string]string{
+				`arc_read_bytes`: `4194304`,
+			},
+		},
+		{
+			name: `missing`,
+			pool: `nonexistent`,
+			err:  os.ErrNotExist,
+		},
+	}
+
+	client, err := New(Config{KstatRoot: `testdata/kstat`, Backend: BackendText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stats, err := client.Pool(tc.pool).IOStats(context.Background())
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stats) != tc.count {
+				t.Errorf("expected %d statistics, got %d", tc.count, len(stats))
+			}
+			for k, v := range tc.expected {
+				if stats[k] != v {
+					t.Errorf("%s = %q, expected %q", k, stats[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestPoolTxgs(t *testing.T) {
+	client, err := New(Config{KstatRoot: `testdata/kstat`, Backend: BackendText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txgs, err := client.Pool(`tank`).Txgs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Txg{
+		{TXG: 12345, Birth: 1034551543542, State: TxgCommitted, Dirty: 2195456, Written: 4497408, Writes: 52, OpenTime: 5000247591, QuiesceTime: 13270, WaitTime: 84160, SyncTime: 28731893},
+		{TXG: 12346, Birth: 1039551791133, State: TxgSyncing, Dirty: 1048576, OpenTime: 5000099839, QuiesceTime: 20391},
+		{TXG: 12347, Birth: 1044551890972, State: TxgOpen},
+	}
+	if !reflect.DeepEqual(txgs, expected) {
+		t.Fatalf("unexpected result:\n%+v\nexpected:\n%+v", txgs, expected)
+	}
+}
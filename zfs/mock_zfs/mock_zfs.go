@@ -10,6 +10,7 @@
 package mock_zfs
 
 import (
+	context "context"
 	reflect "reflect"
 
 	zfs "github.com/pdf/zfs_exporter/v2/zfs"
@@ -40,6 +41,21 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// ARCStats mocks base method.
+func (m *MockClient) ARCStats(ctx context.Context) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ARCStats", ctx)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ARCStats indicates an expected call of ARCStats.
+func (mr *MockClientMockRecorder) ARCStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ARCStats", reflect.TypeOf((*MockClient)(nil).ARCStats), ctx)
+}
+
 // Datasets mocks base method.
 func (m *MockClient) Datasets(pool string, kind zfs.DatasetKind) zfs.Datasets {
 	m.ctrl.T.Helper()
@@ -54,6 +70,26 @@ func (mr *MockClientMockRecorder) Datasets(pool, kind any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Datasets", reflect.TypeOf((*MockClient)(nil).Datasets), pool, kind)
 }
 
+// Holds mocks base method.
+func (m *MockClient) Holds(ctx context.Context, snapshots ...string) ([]zfs.Hold, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range snapshots {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Holds", varargs...)
+	ret0, _ := ret[0].([]zfs.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Holds indicates an expected call of Holds.
+func (mr *MockClientMockRecorder) Holds(ctx any, snapshots ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, snapshots...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Holds", reflect.TypeOf((*MockClient)(nil).Holds), varargs...)
+}
+
 // Pool mocks base method.
 func (m *MockClient) Pool(name string) zfs.Pool {
 	m.ctrl.T.Helper()
@@ -69,18 +105,38 @@ func (mr *MockClientMockRecorder) Pool(name any) *gomock.Call {
 }
 
 // PoolNames mocks base method.
-func (m *MockClient) PoolNames() ([]string, error) {
+func (m *MockClient) PoolNames(ctx context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PoolNames")
+	ret := m.ctrl.Call(m, "PoolNames", ctx)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PoolNames indicates an expected call of PoolNames.
-func (mr *MockClientMockRecorder) PoolNames() *gomock.Call {
+func (mr *MockClientMockRecorder) PoolNames(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PoolNames", reflect.TypeOf((*MockClient)(nil).PoolNames))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PoolNames", reflect.TypeOf((*MockClient)(nil).PoolNames), ctx)
+}
+
+// SpaceUsage mocks base method.
+func (m *MockClient) SpaceUsage(ctx context.Context, dataset string, kind zfs.SpaceKind, props ...string) ([]zfs.SpaceUsage, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, dataset, kind}
+	for _, a := range props {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SpaceUsage", varargs...)
+	ret0, _ := ret[0].([]zfs.SpaceUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SpaceUsage indicates an expected call of SpaceUsage.
+func (mr *MockClientMockRecorder) SpaceUsage(ctx, dataset, kind any, props ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, dataset, kind}, props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpaceUsage", reflect.TypeOf((*MockClient)(nil).SpaceUsage), varargs...)
 }
 
 // MockPool is a mock of Pool interface.
@@ -107,6 +163,21 @@ func (m *MockPool) EXPECT() *MockPoolMockRecorder {
 	return m.recorder
 }
 
+// IOStats mocks base method.
+func (m *MockPool) IOStats(ctx context.Context) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IOStats", ctx)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IOStats indicates an expected call of IOStats.
+func (mr *MockPoolMockRecorder) IOStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IOStats", reflect.TypeOf((*MockPool)(nil).IOStats), ctx)
+}
+
 // Name mocks base method.
 func (m *MockPool) Name() string {
 	m.ctrl.T.Helper()
@@ -122,9 +193,9 @@ func (mr *MockPoolMockRecorder) Name() *gomock.Call {
 }
 
 // Properties mocks base method.
-func (m *MockPool) Properties(props ...string) (zfs.PoolProperties, error) {
+func (m *MockPool) Properties(ctx context.Context, props ...string) (zfs.PoolProperties, error) {
 	m.ctrl.T.Helper()
-	varargs := []any{}
+	varargs := []any{ctx}
 	for _, a := range props {
 		varargs = append(varargs, a)
 	}
@@ -135,9 +206,60 @@ func (m *MockPool) Properties(props ...string) (zfs.PoolProperties, error) {
 }
 
 // Properties indicates an expected call of Properties.
-func (mr *MockPoolMockRecorder) Properties(props ...any) *gomock.Call {
+func (mr *MockPoolMockRecorder) Properties(ctx any, props ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockPool)(nil).Properties), varargs...)
+}
+
+// ScanStatus mocks base method.
+func (m *MockPool) ScanStatus(ctx context.Context) (zfs.ScanStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanStatus", ctx)
+	ret0, _ := ret[0].(zfs.ScanStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanStatus indicates an expected call of ScanStatus.
+func (mr *MockPoolMockRecorder) ScanStatus(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockPool)(nil).Properties), props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanStatus", reflect.TypeOf((*MockPool)(nil).ScanStatus), ctx)
+}
+
+// Txgs mocks base method.
+func (m *MockPool) Txgs(ctx context.Context) ([]zfs.Txg, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Txgs", ctx)
+	ret0, _ := ret[0].([]zfs.Txg)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Txgs indicates an expected call of Txgs.
+func (mr *MockPoolMockRecorder) Txgs(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Txgs", reflect.TypeOf((*MockPool)(nil).Txgs), ctx)
+}
+
+// Vdevs mocks base method.
+func (m *MockPool) Vdevs(ctx context.Context, props ...string) ([]zfs.Vdev, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range props {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Vdevs", varargs...)
+	ret0, _ := ret[0].([]zfs.Vdev)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Vdevs indicates an expected call of Vdevs.
+func (mr *MockPoolMockRecorder) Vdevs(ctx any, props ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vdevs", reflect.TypeOf((*MockPool)(nil).Vdevs), varargs...)
 }
 
 // MockPoolProperties is a mock of PoolProperties interface.
@@ -231,9 +353,9 @@ func (mr *MockDatasetsMockRecorder) Pool() *gomock.Call {
 }
 
 // Properties mocks base method.
-func (m *MockDatasets) Properties(props ...string) ([]zfs.DatasetProperties, error) {
+func (m *MockDatasets) Properties(ctx context.Context, props ...string) ([]zfs.DatasetProperties, error) {
 	m.ctrl.T.Helper()
-	varargs := []any{}
+	varargs := []any{ctx}
 	for _, a := range props {
 		varargs = append(varargs, a)
 	}
@@ -244,9 +366,24 @@ func (m *MockDatasets) Properties(props ...string) ([]zfs.DatasetProperties, err
 }
 
 // Properties indicates an expected call of Properties.
-func (mr *MockDatasetsMockRecorder) Properties(props ...any) *gomock.Call {
+func (mr *MockDatasetsMockRecorder) Properties(ctx any, props ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockDatasets)(nil).Properties), varargs...)
+}
+
+// Root mocks base method.
+func (m *MockDatasets) Root(name string, depth int) zfs.Datasets {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Root", name, depth)
+	ret0, _ := ret[0].(zfs.Datasets)
+	return ret0
+}
+
+// Root indicates an expected call of Root.
+func (mr *MockDatasetsMockRecorder) Root(name, depth any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Properties", reflect.TypeOf((*MockDatasets)(nil).Properties), props...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Root", reflect.TypeOf((*MockDatasets)(nil).Root), name, depth)
 }
 
 // MockDatasetProperties is a mock of DatasetProperties interface.
@@ -1,10 +1,9 @@
 package zfs
 
 import (
-	"bufio"
-	"fmt"
-	"io"
-	"os/exec"
+	"context"
+	"errors"
+	"io/fs"
 	"strings"
 )
 
@@ -29,21 +28,74 @@ const (
 )
 
 type poolImpl struct {
-	name string
+	name   string
+	kstat  kstatReader
+	runner Runner
 }
 
 func (p poolImpl) Name() string {
 	return p.name
 }
 
-func (p poolImpl) Properties(props ...string) (PoolProperties, error) {
+func (p poolImpl) Properties(ctx context.Context, props ...string) (PoolProperties, error) {
 	handler := newPoolPropertiesImpl()
-	if err := execute(p.name, handler, `zpool`, `get`, `-Hpo`, `name,property,value`, strings.Join(props, `,`)); err != nil {
+	selector := newPropertySelector(props)
+	if err := execute(ctx, p.runner, p.name, handler, `zpool`, `get`, `-Hpo`, `name,property,value`, selector.request()); err != nil {
 		return handler, err
 	}
+	selector.filter(handler.properties)
 	return handler, nil
 }
 
+func (p poolImpl) Vdevs(ctx context.Context, props ...string) ([]Vdev, error) {
+	handler := newVdevHandler(p.name, props)
+	if err := run(ctx, p.runner, handler.processStatus, `zpool`, `status`, `-p`, p.name); err != nil {
+		return nil, err
+	}
+	if handler.wantList() {
+		if err := run(ctx, p.runner, handler.processList, `zpool`, `list`, `-vHpo`, `name,`+strings.Join(vdevListProps, `,`), p.name); err != nil {
+			return nil, err
+		}
+	}
+	return handler.result(), nil
+}
+
+func (p poolImpl) ScanStatus(ctx context.Context) (ScanStatus, error) {
+	handler := newScanHandler()
+	if err := run(ctx, p.runner, handler.processStatus, `zpool`, `status`, `-p`, p.name); err != nil {
+		return ScanStatus{}, err
+	}
+	return handler.status, nil
+}
+
+// IOStats returns the combined statistics from the pool `io` kstat, and the `iostats` kstat available in newer
+// versions of OpenZFS. Either may be absent, depending on the ZFS version.
+func (p poolImpl) IOStats(ctx context.Context) (map[string]string, error) {
+	handler := newKstatHandler()
+	ioErr := p.kstat(ctx, handler.processIO, p.name, `io`)
+	if ioErr != nil && !errors.Is(ioErr, fs.ErrNotExist) {
+		return nil, ioErr
+	}
+	err := p.kstat(ctx, handler.processNamed, p.name, `iostats`)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if ioErr != nil && err != nil {
+		return nil, err
+	}
+	return handler.values, nil
+}
+
+// Txgs returns the transaction group history retained by the pool `txgs` kstat, the length of which is controlled by
+// the `zfs_txg_history` module parameter.
+func (p poolImpl) Txgs(ctx context.Context) ([]Txg, error) {
+	handler := newTxgHandler()
+	if err := p.kstat(ctx, handler.processKstat, p.name, `txgs`); err != nil {
+		return nil, err
+	}
+	return handler.txgs, nil
+}
+
 type poolPropertiesImpl struct {
 	properties map[string]string
 }
@@ -62,39 +114,11 @@ func (p *poolPropertiesImpl) processLine(pool string, line []string) error {
 	return nil
 }
 
-// PoolNames returns a list of available pool names
-func poolNames() ([]string, error) {
-	pools := make([]string, 0)
-	cmd := exec.Command(`zpool`, `list`, `-Ho`, `name`)
-	out, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
-	}
-	scanner := bufio.NewScanner(out)
-
-	if err = cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start command '%s': %w", cmd.String(), err)
-	}
-
-	for scanner.Scan() {
-		pools = append(pools, scanner.Text())
-	}
-
-	stde, _ := io.ReadAll(stderr)
-	if err = cmd.Wait(); err != nil {
-		return nil, fmt.Errorf("failed to execute command '%s'; output: '%s' (%w)", cmd.String(), strings.TrimSpace(string(stde)), err)
-	}
-
-	return pools, nil
-}
-
-func newPoolImpl(name string) poolImpl {
+func newPoolImpl(name string, kstat kstatReader, runner Runner) poolImpl {
 	return poolImpl{
-		name: name,
+		name:   name,
+		kstat:  kstat,
+		runner: runner,
 	}
 }
 
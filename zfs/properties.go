@@ -0,0 +1,66 @@
+package zfs
+
+import (
+	"path"
+	"strings"
+)
+
+// allProperties requests all properties from the ZFS tools
+const allProperties = `all`
+
+// propertySelector resolves the requested properties, which may include patterns as accepted by path.Match (ie -
+// `feature@*`), against the properties returned by the ZFS tools
+type propertySelector struct {
+	props    []string
+	patterns bool
+}
+
+// request returns the property list argument for the ZFS tools, requesting all properties if `all` or patterns are
+// present
+func (s propertySelector) request() string {
+	if s.patterns {
+		return allProperties
+	}
+	return strings.Join(s.props, `,`)
+}
+
+// match reports whether the named property was requested
+func (s propertySelector) match(name string) bool {
+	if !s.patterns {
+		return true
+	}
+	for _, p := range s.props {
+		if p == allProperties {
+			return true
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filter removes the properties that were not requested
+func (s propertySelector) filter(properties map[string]string) {
+	for k := range properties {
+		if !s.match(k) {
+			delete(properties, k)
+		}
+	}
+}
+
+func isPropertyPattern(prop string) bool {
+	return strings.ContainsAny(prop, `*?[`)
+}
+
+func newPropertySelector(props []string) propertySelector {
+	s := propertySelector{props: props}
+	for _, p := range props {
+		// The ZFS tools do not accept `all` within a list of properties
+		if p == allProperties || isPropertyPattern(p) {
+			s.patterns = true
+			break
+		}
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package zfs
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func TestPropertySelector(t *testing.T) {
+	properties := map[string]string{
+		`allocated`:             `1024`,
+		`feature@async_destroy`: `enabled`,
+		`health`:                `ONLINE`,
+		`usedbychildren`:        `512`,
+	}
+	testCases := []struct {
+		name     string
+		props    []string
+		request  string
+		expected []string
+	}{
+		{
+			name:     `explicit`,
+			props:    []string{`allocated`, `health`},
+			request:  `allocated,health`,
+			expected: []string{`allocated`, `feature@async_destroy`, `health`, `usedbychildren`},
+		},
+		{
+			name:     `all`,
+			props:    []string{`allocated`, `all`},
+			request:  `all`,
+			expected: []string{`allocated`, `feature@async_destroy`, `health`, `usedbychildren`},
+		},
+		{
+			name:     `patterns`,
+			props:    []string{`health`, `feature@*`, `usedby*`},
+			request:  `all`,
+			expected: []string{`feature@async_destroy`, `health`, `usedbychildren`},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := newPropertySelector(tc.props)
+			if request := s.request(); request != tc.request {
+				t.Fatalf("unexpected request: %s, expected %s", request, tc.request)
+			}
+			var result []string
+			for _, name := range []string{`allocated`, `feature@async_destroy`, `health`, `usedbychildren`} {
+				if s.match(name) {
+					result = append(result, name)
+				}
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Fatalf("unexpected properties: %v, expected %v", result, tc.expected)
+			}
+			filtered := maps.Clone(properties)
+			s.filter(filtered)
+			if len(filtered) != len(tc.expected) {
+				t.Fatalf("unexpected filtered properties: %v", filtered)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package zfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// commandWaitDelay is how long to wait for the output of a cancelled command to be closed, ie - when a command prefix
+// such as `sudo` leaves a child process holding the output open.
+const commandWaitDelay = time.Second
+
+type killedFuncKey struct{}
+
+// WithKilledFunc returns a copy of ctx, such that f is called each time a command executed by the Client with the
+// returned context is terminated due to cancellation of the context, ie - upon exceeding a timeout.
+func WithKilledFunc(ctx context.Context, f func()) context.Context {
+	return context.WithValue(ctx, killedFuncKey{}, f)
+}
+
+// Runner executes commands on behalf of the Client
+type Runner interface {
+	// Run executes the named command, writing its standard output to stdout. The command must be terminated when ctx
+	// is cancelled.
+	Run(ctx context.Context, stdout io.Writer, name string, arg ...string) error
+}
+
+// ExecRunner is the default Runner, executing commands on the local host
+type ExecRunner struct {
+	// Prefix is prepended to each command (e.g. `sudo`, `-n`)
+	Prefix []string
+	// Paths maps command names to the executable to run (e.g. `zpool` => `/usr/local/sbin/zpool`)
+	Paths map[string]string
+	// Env holds additional environment variables for executed commands, in the `key=value` format
+	Env []string
+}
+
+// Run implements the Runner interface
+func (r ExecRunner) Run(ctx context.Context, stdout io.Writer, name string, arg ...string) error {
+	if path, ok := r.Paths[name]; ok {
+		name = path
+	}
+	args := make([]string, 0, len(r.Prefix)+len(arg)+1)
+	args = append(args, r.Prefix...)
+	args = append(args, name)
+	args = append(args, arg...)
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.WaitDelay = commandWaitDelay
+	killProcessGroup(cmd, r.cancelSignal())
+	if len(r.Env) > 0 {
+		cmd.Env = append(os.Environ(), r.Env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("command '%s' cancelled: %w", cmd.String(), ctxErr)
+		}
+		return fmt.Errorf("failed to execute command '%s'; output: '%s' (%w)", cmd.String(), strings.TrimSpace(stderr.String()), err)
+	}
+	return nil
+}
+
+// cancelSignal returns the signal sent to the process group of a command upon cancellation. A `sudo` prefix is sent
+// SIGTERM, which it relays to the command, as the command does not accept signals from an unprivileged user.
+func (r ExecRunner) cancelSignal() syscall.Signal {
+	if len(r.Prefix) > 0 && filepath.Base(r.Prefix[0]) == `sudo` {
+		return syscall.SIGTERM
+	}
+	return syscall.SIGKILL
+}
+
+// ReplayRunner is a Runner that replays recorded output, for use in tests. Keys are space-separated command lines, and
+// values are the path to the file containing the recorded output. Commands without a recording fail.
+type ReplayRunner map[string]string
+
+// Run implements the Runner interface
+func (r ReplayRunner) Run(ctx context.Context, stdout io.Writer, name string, arg ...string) error {
+	line := strings.Join(append([]string{name}, arg...), ` `)
+	path, ok := r[line]
+	if !ok {
+		return fmt.Errorf("failed to execute command '%s'; output: 'unsupported command'", line)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to execute command '%s': %w", line, err)
+	}
+	defer f.Close()
+	if err = ctx.Err(); err != nil {
+		return fmt.Errorf("command '%s' cancelled: %w", line, err)
+	}
+	_, err = io.Copy(stdout, f)
+	return err
+}
+
+// run executes the command via runner, passing its output to the parse function
+func run(ctx context.Context, runner Runner, parse func(io.Reader) error, name string, arg ...string) error {
+	r, w := io.Pipe()
+	runErr := make(chan error, 1)
+	go func() {
+		err := runner.Run(ctx, w, name, arg...)
+		_ = w.CloseWithError(err)
+		runErr <- err
+	}()
+
+	parseErr := parse(r)
+	// Ensure the command is not blocked on a full pipe if parsing terminated early
+	_, _ = io.Copy(io.Discard, r)
+
+	if err := <-runErr; err != nil {
+		if ctx.Err() != nil {
+			if f, ok := ctx.Value(killedFuncKey{}).(func()); ok {
+				f()
+			}
+		}
+		return err
+	}
+	return parseErr
+}
+
+// execute runs the command with the pool name as the final argument, passing each line of tab-separated output to the
+// handler
+func execute(ctx context.Context, runner Runner, pool string, h handler, name string, arg ...string) error {
+	return run(ctx, runner, func(out io.Reader) error {
+		r := csv.NewReader(out)
+		r.Comma = '\t'
+		r.LazyQuotes = true
+		r.ReuseRecord = true
+		r.FieldsPerRecord = 3
+
+		for {
+			line, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err = h.processLine(pool, line); err != nil {
+				return err
+			}
+		}
+	}, name, append(arg, pool)...)
+}
+
+// poolNames returns a list of available pool names
+func poolNames(ctx context.Context, runner Runner) ([]string, error) {
+	pools := make([]string, 0)
+	err := run(ctx, runner, func(out io.Reader) error {
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			pools = append(pools, scanner.Text())
+		}
+		return scanner.Err()
+	}, `zpool`, `list`, `-Ho`, `name`)
+	if err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package zfs
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup is a no-op where process groups are not supported, only the command itself is killed upon
+// cancellation
+func killProcessGroup(*exec.Cmd, syscall.Signal) {}
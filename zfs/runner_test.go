@@ -0,0 +1,70 @@
+package zfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExecRunner(t *testing.T) {
+	testCases := []struct {
+		name     string
+		runner   ExecRunner
+		command  []string
+		expected string
+	}{
+		{
+			name:     `path`,
+			runner:   ExecRunner{Paths: map[string]string{`zpool`: `echo`}},
+			command:  []string{`zpool`, `list`, `-Ho`, `name`},
+			expected: "list -Ho name\n",
+		},
+		{
+			name: `prefix and environment`,
+			runner: ExecRunner{
+				Prefix: []string{`env`},
+				Paths:  map[string]string{`zpool`: `printenv`},
+				Env:    []string{`ZFS_EXPORTER_TEST=ok`},
+			},
+			command:  []string{`zpool`, `ZFS_EXPORTER_TEST`},
+			expected: "ok\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := tc.runner.Run(context.Background(), &out, tc.command[0], tc.command[1:]...); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("unexpected output %q, expected %q", out.String(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestExecRunnerCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	err := ExecRunner{}.Run(ctx, &bytes.Buffer{}, `sleep`, `10`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Errorf("command was not terminated upon cancellation, took %s", elapsed)
+	}
+}
+
+func TestReplayRunnerUnsupported(t *testing.T) {
+	client, err := New(Config{Backend: BackendText, Runner: ReplayRunner{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.PoolNames(context.Background()); err == nil {
+		t.Fatal(`expected error, got nil`)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package zfs
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup runs the command in a new process group, which is sent sig upon cancellation, such that child
+// processes of the command (ie - when run via a prefix such as `sudo`) are also terminated
+func killProcessGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		err := syscall.Kill(-cmd.Process.Pid, sig)
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+}
@@ -0,0 +1,54 @@
+//go:build unix
+
+package zfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// processRunning reports whether the process is running, excluding zombies that have yet to be reaped
+func processRunning(pid int) bool {
+	out, err := exec.Command(`ps`, `-o`, `stat=`, `-p`, strconv.Itoa(pid)).Output()
+	if err != nil {
+		return false
+	}
+	stat := strings.TrimSpace(string(out))
+	return stat != `` && !strings.HasPrefix(stat, `Z`)
+}
+
+func TestExecRunnerCancelProcessGroup(t *testing.T) {
+	// The prefix runs the command as a grandchild of the runner, as with `sudo`, and reports its pid
+	runner := ExecRunner{Prefix: []string{`sh`, `-c`, `"$@" & echo $!; wait`, `sh`}}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	if err := runner.Run(ctx, &out, `sleep`, `10`); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(out.String()))
+	if err != nil {
+		t.Fatalf("unexpected output %q: %v", out.String(), err)
+	}
+	for begin := time.Now(); processRunning(pid); time.Sleep(10 * time.Millisecond) {
+		if time.Since(begin) > 5*time.Second {
+			t.Fatalf("child process %d was not terminated upon cancellation", pid)
+		}
+	}
+}
+
+func TestExecRunnerCancelSignal(t *testing.T) {
+	if sig := (ExecRunner{Prefix: []string{`/usr/bin/sudo`, `-n`}}).cancelSignal(); sig != syscall.SIGTERM {
+		t.Errorf("expected SIGTERM for sudo prefix, got %v", sig)
+	}
+	if sig := (ExecRunner{}).cancelSignal(); sig != syscall.SIGKILL {
+		t.Errorf("expected SIGKILL without prefix, got %v", sig)
+	}
+}
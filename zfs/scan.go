@@ -0,0 +1,266 @@
+package zfs
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScanFunction enum contains the type of scan
+type ScanFunction string
+
+const (
+	// ScanFunctionNone enum entry
+	ScanFunctionNone ScanFunction = `none`
+	// ScanFunctionScrub enum entry
+	ScanFunctionScrub ScanFunction = `scrub`
+	// ScanFunctionResilver enum entry
+	ScanFunctionResilver ScanFunction = `resilver`
+	// ScanFunctionUnknown enum entry
+	ScanFunctionUnknown ScanFunction = `unknown`
+)
+
+// ScanState enum contains the state of a scan
+type ScanState string
+
+const (
+	// ScanStateNone enum entry
+	ScanStateNone ScanState = `none`
+	// ScanStateScanning enum entry
+	ScanStateScanning ScanState = `scanning`
+	// ScanStatePaused enum entry
+	ScanStatePaused ScanState = `paused`
+	// ScanStateFinished enum entry
+	ScanStateFinished ScanState = `finished`
+	// ScanStateCanceled enum entry
+	ScanStateCanceled ScanState = `canceled`
+	// ScanStateUnknown enum entry, for scan output that is not recognised (ie - from a newer release)
+	ScanStateUnknown ScanState = `unknown`
+)
+
+const scanTimeLayout = `Mon Jan 2 15:04:05 2006`
+
+var (
+	scanFinishedRe  = regexp.MustCompile(`^(scrub repaired|resilvered) (\S+) in (.+) with (\d+) errors on (.+)$`)
+	scanCanceledRe  = regexp.MustCompile(`^(scrub|resilver) canceled on (.+)$`)
+	scanActiveRe    = regexp.MustCompile(`^(scrub|resilver) (in progress|paused) since (.+)$`)
+	scanStartedRe   = regexp.MustCompile(`^(?:scrub|resilver) started on (.+)$`)
+	scanProgressRe  = regexp.MustCompile(`^(\S+) / (\S+) scanned(?: at \S+)?, (\S+) / \S+ issued`)
+	scanLegacyRe    = regexp.MustCompile(`^(\S+) scanned(?: at \S+)?, (\S+) issued(?: at \S+)?, (\S+) total`)
+	scanRepairedRe  = regexp.MustCompile(`^(\S+) (?:repaired|resilvered), ([\d.]+)% done(?:, (.+) to go)?`)
+	scanDurationRe  = regexp.MustCompile(`^(?:(\d+) days )?(\d+):(\d+):(\d+)$`)
+	niceNumSuffixes = `BKMGTPE`
+)
+
+// ScanStatus contains the status of the most recent scrub or resilver of a pool
+type ScanStatus struct {
+	Function ScanFunction
+	State    ScanState
+	// Start is the time the scan started, zero if unknown
+	Start time.Time
+	// End is the time the scan finished or was canceled, zero if the scan has not ended
+	End time.Time
+	// ToProcess is the number of bytes the scan must process
+	ToProcess uint64
+	// Scanned is the number of bytes of metadata that have been scanned
+	Scanned uint64
+	// Issued is the number of bytes that have been issued for verification
+	Issued uint64
+	// Repaired is the number of bytes repaired by a scrub, or resilvered by a resilver
+	Repaired uint64
+	// Errors is the number of errors encountered by a finished scan
+	Errors uint64
+	// Progress is the ratio of the scan that has completed
+	Progress float64
+	// Remaining is the estimated duration until the scan completes, zero if unknown
+	Remaining time.Duration
+	// Unrecognised is the scan output that could not be parsed, when the state is ScanStateUnknown
+	Unrecognised string
+}
+
+// scanHandler parses the scan section of `zpool status` output
+type scanHandler struct {
+	loc    *time.Location
+	status ScanStatus
+}
+
+// processStatus reads the scan section of `zpool status` output.
+func (h *scanHandler) processStatus(r io.Reader) error {
+	var (
+		scanner = bufio.NewScanner(r)
+		lines   []string
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if lines == nil {
+			if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, `scan:`) {
+				lines = append(lines, strings.TrimSpace(strings.TrimPrefix(trimmed, `scan:`)))
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			break
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	h.status = ScanStatus{Function: ScanFunctionNone, State: ScanStateNone}
+	if len(lines) == 0 || lines[0] == `none requested` {
+		return nil
+	}
+
+	return h.parse(lines)
+}
+
+func (h *scanHandler) parse(lines []string) error {
+	var err error
+	s := &h.status
+	if m := scanFinishedRe.FindStringSubmatch(lines[0]); m != nil {
+		s.Function = scanFunction(m[1])
+		s.State = ScanStateFinished
+		s.Progress = 1
+		if s.Repaired, err = parseNiceNum(m[2]); err != nil {
+			return err
+		}
+		duration, err := parseScanDuration(m[3])
+		if err != nil {
+			return err
+		}
+		if s.Errors, err = strconv.ParseUint(m[4], 10, 64); err != nil {
+			return err
+		}
+		if s.End, err = h.parseTime(m[5]); err != nil {
+			return err
+		}
+		s.Start = s.End.Add(-duration)
+		return nil
+	}
+
+	if m := scanCanceledRe.FindStringSubmatch(lines[0]); m != nil {
+		s.Function = scanFunction(m[1])
+		s.State = ScanStateCanceled
+		s.End, err = h.parseTime(m[2])
+		return err
+	}
+
+	m := scanActiveRe.FindStringSubmatch(lines[0])
+	if m == nil {
+		s.Function = ScanFunctionUnknown
+		s.State = ScanStateUnknown
+		s.Unrecognised = lines[0]
+		return nil
+	}
+	s.Function = scanFunction(m[1])
+	s.State = ScanStateScanning
+	if m[2] == `paused` {
+		s.State = ScanStatePaused
+	} else if s.Start, err = h.parseTime(m[3]); err != nil {
+		return err
+	}
+
+	for _, line := range lines[1:] {
+		if m := scanStartedRe.FindStringSubmatch(line); m != nil {
+			if s.Start, err = h.parseTime(m[1]); err != nil {
+				return err
+			}
+		} else if m := scanProgressRe.FindStringSubmatch(line); m != nil {
+			if err = parseNiceNums(m[1:], &s.Scanned, &s.ToProcess, &s.Issued); err != nil {
+				return err
+			}
+		} else if m := scanLegacyRe.FindStringSubmatch(line); m != nil {
+			if err = parseNiceNums(m[1:], &s.Scanned, &s.Issued, &s.ToProcess); err != nil {
+				return err
+			}
+		} else if m := scanRepairedRe.FindStringSubmatch(line); m != nil {
+			if s.Repaired, err = parseNiceNum(m[1]); err != nil {
+				return err
+			}
+			progress, err := strconv.ParseFloat(m[2], 64)
+			if err != nil {
+				return err
+			}
+			s.Progress = progress / 100
+			if m[3] != `` {
+				if s.Remaining, err = parseScanDuration(m[3]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *scanHandler) parseTime(value string) (time.Time, error) {
+	// Dates are formatted by ctime(3), which pads the day of the month with spaces.
+	return time.ParseInLocation(scanTimeLayout, strings.Join(strings.Fields(value), ` `), h.loc)
+}
+
+func scanFunction(value string) ScanFunction {
+	if strings.HasPrefix(value, `resilver`) {
+		return ScanFunctionResilver
+	}
+	return ScanFunctionScrub
+}
+
+// parseScanDuration parses durations in the format `[D days ]HH:MM:SS`.
+func parseScanDuration(value string) (time.Duration, error) {
+	m := scanDurationRe.FindStringSubmatch(value)
+	if m == nil {
+		return 0, ErrInvalidOutput
+	}
+	var result time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+1] == `` {
+			continue
+		}
+		v, err := strconv.ParseUint(m[i+1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		result += time.Duration(v) * unit
+	}
+
+	return result, nil
+}
+
+// parseNiceNum parses byte values in either raw or human-readable format, ie - `1024`, `512B`, `1.21G`.
+func parseNiceNum(value string) (uint64, error) {
+	if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return v, nil
+	}
+	if len(value) < 2 {
+		return 0, ErrInvalidOutput
+	}
+	exp := strings.IndexByte(niceNumSuffixes, value[len(value)-1])
+	if exp < 0 {
+		return 0, ErrInvalidOutput
+	}
+	v, err := strconv.ParseFloat(value[:len(value)-1], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(v * math.Pow(1024, float64(exp))), nil
+}
+
+func parseNiceNums(values []string, results ...*uint64) error {
+	var err error
+	for i, result := range results {
+		if *result, err = parseNiceNum(values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newScanHandler() *scanHandler {
+	return &scanHandler{loc: time.Local}
+}
@@ -0,0 +1,123 @@
+package zfs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanHandler(t *testing.T) {
+	testCases := []struct {
+		name   string
+		output string
+		status ScanStatus
+	}{
+		{
+			name:   `none requested`,
+			output: "  pool: tank\n state: ONLINE\n  scan: none requested\nconfig:\n",
+			status: ScanStatus{Function: ScanFunctionNone, State: ScanStateNone},
+		},
+		{
+			name:   `no scan section`,
+			output: "  pool: tank\n state: ONLINE\nconfig:\n",
+			status: ScanStatus{Function: ScanFunctionNone, State: ScanStateNone},
+		},
+		{
+			name:   `scrub finished`,
+			output: "  pool: tank\n state: ONLINE\n  scan: scrub repaired 1.50K in 1 days 00:10:23 with 2 errors on Sun Oct  6 00:34:24 2024\nconfig:\n",
+			status: ScanStatus{
+				Function: ScanFunctionScrub,
+				State:    ScanStateFinished,
+				Start:    time.Date(2024, 10, 5, 0, 24, 1, 0, time.UTC),
+				End:      time.Date(2024, 10, 6, 0, 34, 24, 0, time.UTC),
+				Repaired: 1536,
+				Errors:   2,
+				Progress: 1,
+			},
+		},
+		{
+			name:   `resilver finished`,
+			output: "  pool: tank\n state: ONLINE\n  scan: resilvered 512B in 00:00:10 with 0 errors on Sun Oct 13 00:00:10 2024\nconfig:\n",
+			status: ScanStatus{
+				Function: ScanFunctionResilver,
+				State:    ScanStateFinished,
+				Start:    time.Date(2024, 10, 13, 0, 0, 0, 0, time.UTC),
+				End:      time.Date(2024, 10, 13, 0, 0, 10, 0, time.UTC),
+				Repaired: 512,
+				Progress: 1,
+			},
+		},
+		{
+			name:   `scrub canceled`,
+			output: "  pool: tank\n state: ONLINE\n  scan: scrub canceled on Sun Oct 13 00:34:24 2024\nconfig:\n",
+			status: ScanStatus{
+				Function: ScanFunctionScrub,
+				State:    ScanStateCanceled,
+				End:      time.Date(2024, 10, 13, 0, 34, 24, 0, time.UTC),
+			},
+		},
+		{
+			name:   `scrub in progress`,
+			output: "  pool: tank\n state: ONLINE\n  scan: scrub in progress since Sun Oct 13 00:24:01 2024\n\t1.50T / 2.00T scanned at 500M/s, 1.00T / 2.00T issued at 400M/s\n\t0B repaired, 50.00% done, 00:30:00 to go\nconfig:\n",
+			status: ScanStatus{
+				Function:  ScanFunctionScrub,
+				State:     ScanStateScanning,
+				Start:     time.Date(2024, 10, 13, 0, 24, 1, 0, time.UTC),
+				ToProcess: 2 << 40,
+				Scanned:   3 << 39,
+				Issued:    1 << 40,
+				Progress:  0.5,
+				Remaining: 30 * time.Minute,
+			},
+		},
+		{
+			name:   `resilver in progress legacy`,
+			output: "  pool: tank\n state: DEGRADED\nstatus: One or more devices is currently being resilvered.\n\tThe pool will continue to function.\n  scan: resilver in progress since Sun Oct 13 00:24:01 2024\n\t1.50T scanned at 500M/s, 1.00T issued at 400M/s, 2.00T total\n\t256G resilvered, 25.00% done, no estimated completion time\nconfig:\n",
+			status: ScanStatus{
+				Function:  ScanFunctionResilver,
+				State:     ScanStateScanning,
+				Start:     time.Date(2024, 10, 13, 0, 24, 1, 0, time.UTC),
+				ToProcess: 2 << 40,
+				Scanned:   3 << 39,
+				Issued:    1 << 40,
+				Repaired:  1 << 38,
+				Progress:  0.25,
+			},
+		},
+		{
+			name:   `scrub paused`,
+			output: "  pool: tank\n state: ONLINE\n  scan: scrub paused since Sun Oct 13 01:00:00 2024\n\tscrub started on Sun Oct 13 00:24:01 2024\n\t1.50T / 2.00T scanned, 1.00T / 2.00T issued\n\t0B repaired, 50.00% done\nconfig:\n",
+			status: ScanStatus{
+				Function:  ScanFunctionScrub,
+				State:     ScanStatePaused,
+				Start:     time.Date(2024, 10, 13, 0, 24, 1, 0, time.UTC),
+				ToProcess: 2 << 40,
+				Scanned:   3 << 39,
+				Issued:    1 << 40,
+				Progress:  0.5,
+			},
+		},
+		{
+			name:   `unrecognised`,
+			output: "  pool: tank\n state: ONLINE\n  scan: error scrub repaired 0B in 00:00:01 with 0 errors on Sun Oct  6 00:34:24 2024\nconfig:\n",
+			status: ScanStatus{
+				Function:     ScanFunctionUnknown,
+				State:        ScanStateUnknown,
+				Unrecognised: `error scrub repaired 0B in 00:00:01 with 0 errors on Sun Oct  6 00:34:24 2024`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newScanHandler()
+			h.loc = time.UTC
+			if err := h.processStatus(strings.NewReader(tc.output)); err != nil {
+				t.Fatal(err)
+			}
+			if h.status != tc.status {
+				t.Fatalf("unexpected result:\n%+v\nexpected:\n%+v", h.status, tc.status)
+			}
+		})
+	}
+}
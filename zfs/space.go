@@ -0,0 +1,63 @@
+package zfs
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// SpaceKind enum of supported space accounting types
+type SpaceKind string
+
+const (
+	// SpaceUser enum entry
+	SpaceUser SpaceKind = `user`
+	// SpaceGroup enum entry
+	SpaceGroup SpaceKind = `group`
+	// SpaceProject enum entry
+	SpaceProject SpaceKind = `project`
+)
+
+// SpaceUsage contains the space accounting properties for a user, group or project within a dataset, as reported by
+// `zfs userspace`, `zfs groupspace` or `zfs projectspace`
+type SpaceUsage struct {
+	Name       string
+	Properties map[string]string
+}
+
+// spaceUsage runs the space accounting command for the dataset, returning the requested properties for each user,
+// group or project
+func spaceUsage(ctx context.Context, runner Runner, dataset string, kind SpaceKind, props ...string) ([]SpaceUsage, error) {
+	var result []SpaceUsage
+	err := run(ctx, runner, func(out io.Reader) error {
+		r := csv.NewReader(out)
+		r.Comma = '\t'
+		r.LazyQuotes = true
+		r.FieldsPerRecord = len(props) + 1
+
+		for {
+			line, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			usage := SpaceUsage{
+				Name:       line[0],
+				Properties: make(map[string]string, len(props)),
+			}
+			for i, prop := range props {
+				usage.Properties[prop] = line[i+1]
+			}
+			result = append(result, usage)
+		}
+	}, `zfs`, string(kind)+`space`, `-Hp`, `-o`, strings.Join(append([]string{`name`}, props...), `,`), dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
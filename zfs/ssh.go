@@ -0,0 +1,130 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// DefaultSSHCommand is the ssh client used to execute commands on remote hosts
+const DefaultSSHCommand = `ssh`
+
+// sshErrorExitCode is the exit status of the ssh client upon an error of its own, rather than of the remote command
+const sshErrorExitCode = 255
+
+// ErrConnection is returned when a command could not be executed due to a failure to connect to the remote host
+var ErrConnection = errors.New(`failed to connect to host`)
+
+// SSHConfig configures the ssh client used by a remote ZFS Client
+type SSHConfig struct {
+	// Command is the ssh client executable (default: DefaultSSHCommand)
+	Command string
+	// User is the remote login user (default: the ssh client default)
+	User string
+	// IdentityFile is the private key used to authenticate (default: the ssh client default)
+	IdentityFile string
+	// Options are additional ssh client options, in the `-o` format (e.g. `StrictHostKeyChecking=yes`)
+	Options []string
+}
+
+// args returns the ssh client arguments to connect to target, which is either a host, or a host:port pair
+func (s SSHConfig) args(target string) []string {
+	args := []string{`-o`, `BatchMode=yes`}
+	if s.IdentityFile != `` {
+		args = append(args, `-i`, s.IdentityFile)
+	}
+	if s.User != `` {
+		args = append(args, `-l`, s.User)
+	}
+	for _, opt := range s.Options {
+		args = append(args, `-o`, opt)
+	}
+	host := target
+	if h, port, err := net.SplitHostPort(target); err == nil {
+		host = h
+		args = append(args, `-p`, port)
+	}
+
+	return append(args, `--`, host)
+}
+
+// sshRunner is a Runner that executes commands on target via the ssh client, which is run by runner
+type sshRunner struct {
+	command string
+	args    []string
+	runner  Runner
+}
+
+// Run implements the Runner interface
+func (r sshRunner) Run(ctx context.Context, stdout io.Writer, name string, arg ...string) error {
+	remote := make([]string, 0, len(arg)+1)
+	remote = append(remote, shellQuote(name))
+	for _, a := range arg {
+		remote = append(remote, shellQuote(a))
+	}
+	args := make([]string, 0, len(r.args)+1)
+	args = append(args, r.args...)
+	err := r.runner.Run(ctx, stdout, r.command, append(args, strings.Join(remote, ` `))...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == sshErrorExitCode {
+		return fmt.Errorf("%w: %w", ErrConnection, err)
+	}
+	return err
+}
+
+func newSSHRunner(target string, config SSHConfig, runner Runner) sshRunner {
+	return sshRunner{
+		command: config.Command,
+		args:    config.args(target),
+		runner:  runner,
+	}
+}
+
+// sshKstatReader returns a kstatReader for kstat files below root on the remote host
+func sshKstatReader(root string, runner Runner) kstatReader {
+	return func(ctx context.Context, parse func(io.Reader) error, p ...string) error {
+		err := run(ctx, runner, parse, `cat`, path.Join(append([]string{root}, p...)...))
+		if err != nil && strings.Contains(strings.ToLower(err.Error()), `no such file or directory`) {
+			return fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+		}
+		return err
+	}
+}
+
+// shellQuote quotes s for the remote shell, if it contains any characters with special meaning
+func shellQuote(s string) string {
+	if s != `` && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(`,-./:=@_%+`, r))
+	}) < 0 {
+		return s
+	}
+	return `'` + strings.ReplaceAll(s, `'`, `'\''`) + `'`
+}
+
+// NewSSH instantiates a ZFS Client that executes the ZFS tools, and reads kstat statistics, on the target host via
+// ssh. The KstatRoot in config refers to the directory on the remote host, and the Runner executes the ssh client.
+func NewSSH(target string, config Config, ssh SSHConfig) (Client, error) {
+	if target == `` {
+		return nil, errors.New(`ssh target must not be empty`)
+	}
+	if config.KstatRoot == `` {
+		config.KstatRoot = DefaultKstatRoot
+	}
+	if ssh.Command == `` {
+		ssh.Command = DefaultSSHCommand
+	}
+	if config.Runner == nil {
+		config.Runner = ExecRunner{}
+	}
+	runner := newSSHRunner(target, ssh, config.Runner)
+	return newClient(clientImpl{
+		kstat:  sshKstatReader(config.KstatRoot, runner),
+		runner: runner,
+	}, config.Backend, config.DetectTimeout)
+}
@@ -0,0 +1,158 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeSSH is a Runner that verifies the ssh client invocation, and replays the recorded output for the remote command
+// line
+type fakeSSH struct {
+	t            *testing.T
+	expectedArgs []string
+	replay       ReplayRunner
+}
+
+func (f fakeSSH) Run(ctx context.Context, stdout io.Writer, name string, arg ...string) error {
+	if name != `fakessh` {
+		f.t.Errorf("unexpected ssh command: %s", name)
+	}
+	if len(arg) == 0 || !reflect.DeepEqual(arg[:len(arg)-1], f.expectedArgs) {
+		f.t.Errorf("unexpected ssh arguments: %q", arg)
+		return errors.New(`unexpected ssh arguments`)
+	}
+	return f.replay.Run(ctx, stdout, arg[len(arg)-1])
+}
+
+func newSSHFixtureClient(t *testing.T, fixtures ReplayRunner) Client {
+	t.Helper()
+	config := SSHConfig{
+		Command:      `fakessh`,
+		User:         `monitor`,
+		IdentityFile: `/etc/zfs_exporter/id_ed25519`,
+		Options:      []string{`StrictHostKeyChecking=yes`},
+	}
+	expectedArgs := []string{
+		`-o`, `BatchMode=yes`,
+		`-i`, `/etc/zfs_exporter/id_ed25519`,
+		`-l`, `monitor`,
+		`-o`, `StrictHostKeyChecking=yes`,
+		`-p`, `2222`,
+		`--`, `nas.example.com`,
+	}
+	client, err := NewSSH(`nas.example.com:2222`, Config{
+		Backend: BackendText,
+		Runner:  fakeSSH{t: t, expectedArgs: expectedArgs, replay: fixtures},
+	}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestSSHClient(t *testing.T) {
+	client := newSSHFixtureClient(t, ReplayRunner{
+		`zpool list -Ho name`: `testdata/text/zpool-list.txt`,
+		`zfs get -Hprt filesystem -o name,property,value used,available tank`: `testdata/text/zfs-get-filesystem.txt`,
+		`cat /proc/spl/kstat/zfs/tank/io`:                                     `testdata/kstat/tank/io`,
+		`cat /proc/spl/kstat/zfs/tank/iostats`:                                `testdata/kstat/tank/iostats`,
+	})
+
+	pools, err := client.PoolNames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pools, []string{`backup`, `tank`}) {
+		t.Errorf("unexpected pools: %v", pools)
+	}
+
+	datasets, err := client.Datasets(`tank`, DatasetFilesystem).Properties(context.Background(), `used`, `available`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datasets) != 2 {
+		t.Errorf("expected 2 datasets, got %d", len(datasets))
+	}
+
+	stats, err := client.Pool(`tank`).IOStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) == 0 {
+		t.Error(`expected pool IO statistics`)
+	}
+}
+
+func TestSSHKstatMissing(t *testing.T) {
+	client := newSSHFixtureClient(t, ReplayRunner{
+		`cat /proc/spl/kstat/zfs/arcstats`: `testdata/nonexistent/arcstats`,
+	})
+	if _, err := client.ARCStats(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist, got %v", err)
+	}
+}
+
+// exitRunner is a Runner that executes a command exiting with the status, or blocks until cancelled if negative
+type exitRunner int
+
+func (r exitRunner) Run(ctx context.Context, stdout io.Writer, name string, arg ...string) error {
+	if r < 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return exec.CommandContext(ctx, `sh`, `-c`, fmt.Sprintf("exit %d", r)).Run()
+}
+
+func TestSSHDetectBackend(t *testing.T) {
+	testCases := []struct {
+		name     string
+		runner   exitRunner
+		expected error
+	}{
+		{name: `unsupported`, runner: 1},
+		{name: `connection failure`, runner: sshErrorExitCode, expected: ErrConnection},
+		{name: `timeout`, runner: -1, expected: context.DeadlineExceeded},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := NewSSH(`nas.example.com`, Config{Runner: tc.runner, DetectTimeout: 50 * time.Millisecond}, SSHConfig{})
+			if tc.expected == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := client.(clientImpl); !ok {
+					t.Errorf("expected text backend, got %T", client)
+				}
+				return
+			}
+			if !errors.Is(err, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	testCases := map[string]string{
+		`zpool`:                  `zpool`,
+		`name,property,value`:    `name,property,value`,
+		`/proc/spl/kstat/zfs/io`: `/proc/spl/kstat/zfs/io`,
+		``:                       `''`,
+		`tank/my data`:           `'tank/my data'`,
+		`tank/it's`:              `'tank/it'\''s'`,
+		`$(reboot)`:              `'$(reboot)'`,
+	}
+	for input, expected := range testCases {
+		if actual := shellQuote(input); actual != expected {
+			t.Errorf("shellQuote(%q) = %q, expected %q", input, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package zfs
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TxgState enum contains the state of a transaction group
+type TxgState string
+
+const (
+	// TxgOpen enum entry
+	TxgOpen TxgState = `O`
+	// TxgQuiescing enum entry
+	TxgQuiescing TxgState = `Q`
+	// TxgWaiting enum entry
+	TxgWaiting TxgState = `W`
+	// TxgSyncing enum entry
+	TxgSyncing TxgState = `S`
+	// TxgCommitted enum entry
+	TxgCommitted TxgState = `C`
+)
+
+// Txg contains the statistics for a transaction group, as reported by the pool `txgs` kstat. Times are in
+// nanoseconds.
+type Txg struct {
+	TXG         uint64
+	Birth       uint64
+	State       TxgState
+	Dirty       uint64
+	Read        uint64
+	Written     uint64
+	Reads       uint64
+	Writes      uint64
+	OpenTime    uint64
+	QuiesceTime uint64
+	WaitTime    uint64
+	SyncTime    uint64
+}
+
+// txgHandler parses the pool `txgs` kstat
+type txgHandler struct {
+	txgs []Txg
+}
+
+// processKstat reads the `txgs` kstat, consisting of a header line, a column header line, and one line per
+// transaction group.
+func (h *txgHandler) processKstat(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case line == 1:
+			continue
+		case line == 2:
+			if len(fields) < 12 || fields[0] != `txg` || fields[2] != `state` {
+				return ErrInvalidOutput
+			}
+			continue
+		case len(fields) == 0:
+			continue
+		case len(fields) < 12:
+			return ErrInvalidOutput
+		}
+
+		txg := Txg{State: TxgState(fields[2])}
+		values := []*uint64{
+			&txg.TXG, &txg.Birth, nil, &txg.Dirty, &txg.Read, &txg.Written, &txg.Reads, &txg.Writes,
+			&txg.OpenTime, &txg.QuiesceTime, &txg.WaitTime, &txg.SyncTime,
+		}
+		for i, v := range values {
+			if v == nil {
+				continue
+			}
+			var err error
+			if *v, err = strconv.ParseUint(fields[i], 10, 64); err != nil {
+				return err
+			}
+		}
+		h.txgs = append(h.txgs, txg)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if line < 2 {
+		return ErrInvalidOutput
+	}
+
+	return nil
+}
+
+func newTxgHandler() *txgHandler {
+	return &txgHandler{
+		txgs: make([]Txg, 0),
+	}
+}
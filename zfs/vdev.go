@@ -0,0 +1,252 @@
+package zfs
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// VdevType enum contains the type of a vdev
+type VdevType string
+
+const (
+	// VdevRoot enum entry
+	VdevRoot VdevType = `root`
+	// VdevMirror enum entry
+	VdevMirror VdevType = `mirror`
+	// VdevRaidz enum entry
+	VdevRaidz VdevType = `raidz`
+	// VdevDraid enum entry
+	VdevDraid VdevType = `draid`
+	// VdevReplacing enum entry
+	VdevReplacing VdevType = `replacing`
+	// VdevSpare enum entry
+	VdevSpare VdevType = `spare`
+	// VdevIndirect enum entry
+	VdevIndirect VdevType = `indirect`
+	// VdevDisk enum entry
+	VdevDisk VdevType = `disk`
+)
+
+// VdevClass enum contains the allocation class of a vdev
+type VdevClass string
+
+const (
+	// VdevClassNormal enum entry
+	VdevClassNormal VdevClass = `normal`
+	// VdevClassSpecial enum entry
+	VdevClassSpecial VdevClass = `special`
+	// VdevClassDedup enum entry
+	VdevClassDedup VdevClass = `dedup`
+	// VdevClassLog enum entry
+	VdevClassLog VdevClass = `log`
+	// VdevClassCache enum entry
+	VdevClassCache VdevClass = `cache`
+	// VdevClassSpare enum entry
+	VdevClassSpare VdevClass = `spare`
+)
+
+const (
+	// VdevAvail enum entry, only reported for spares
+	VdevAvail PoolStatus = `AVAIL`
+	// VdevInUse enum entry, only reported for spares
+	VdevInUse PoolStatus = `INUSE`
+)
+
+var (
+	vdevClassHeaders = map[string]VdevClass{
+		`special`: VdevClassSpecial,
+		`dedup`:   VdevClassDedup,
+		`logs`:    VdevClassLog,
+		`cache`:   VdevClassCache,
+		`spares`:  VdevClassSpare,
+	}
+	vdevStatusProps = []string{`health`, `read_errors`, `write_errors`, `checksum_errors`}
+	vdevListProps   = []string{`size`, `allocated`, `free`}
+)
+
+// Vdev contains the properties of a vdev or leaf device within a pool
+type Vdev struct {
+	Name       string
+	Parent     string
+	Type       VdevType
+	Class      VdevClass
+	Properties map[string]string
+}
+
+// vdevHandler parses `zpool status` and `zpool list -v` output into Vdev structs
+type vdevHandler struct {
+	pool  string
+	props map[string]struct{}
+	vdevs []*Vdev
+	index map[string]*Vdev
+}
+
+// processStatus reads the config section of `zpool status -p` output.
+func (h *vdevHandler) processStatus(r io.Reader) error {
+	var (
+		scanner  = bufio.NewScanner(r)
+		inConfig bool
+		class    = VdevClassNormal
+		parents  []string
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !inConfig {
+			if strings.TrimSpace(line) == `config:` {
+				inConfig = true
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			if len(h.vdevs) > 0 && strings.TrimSpace(line) != `` {
+				break
+			}
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line[1:], ` `)
+		fields := strings.Fields(trimmed)
+		if len(fields) == 0 || fields[0] == `NAME` {
+			continue
+		}
+		depth := (len(line) - 1 - len(trimmed)) / 2
+
+		if depth == 0 {
+			if c, ok := vdevClassHeaders[fields[0]]; ok && len(fields) == 1 {
+				class = c
+				parents = []string{h.pool}
+				continue
+			}
+			if fields[0] != h.pool {
+				return ErrInvalidOutput
+			}
+			class = VdevClassNormal
+		}
+		if depth > len(parents) {
+			return ErrInvalidOutput
+		}
+		parents = parents[:depth]
+
+		vdev := &Vdev{
+			Name:       fields[0],
+			Type:       vdevType(fields[0], depth),
+			Class:      class,
+			Properties: make(map[string]string),
+		}
+		if depth > 0 {
+			vdev.Parent = parents[depth-1]
+		}
+		for i, prop := range vdevStatusProps {
+			if i+1 >= len(fields) {
+				break
+			}
+			if _, ok := h.props[prop]; ok {
+				vdev.Properties[prop] = fields[i+1]
+			}
+		}
+
+		h.add(vdev)
+		parents = append(parents, vdev.Name)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(h.vdevs) == 0 {
+		return ErrInvalidOutput
+	}
+
+	return nil
+}
+
+// processList reads the output of `zpool list -vHp`, which does not preserve the vdev hierarchy, so vdevs are matched
+// by name to those found by processStatus.
+func (h *vdevHandler) processList(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimLeft(scanner.Text(), "\t"), "\t")
+		if len(fields) == 1 {
+			// Allocation class headings are not tab-separated.
+			continue
+		}
+		if len(fields) != len(vdevListProps)+1 {
+			return ErrInvalidOutput
+		}
+		vdev, ok := h.index[strings.TrimSpace(fields[0])]
+		if !ok {
+			continue
+		}
+		for i, prop := range vdevListProps {
+			if _, ok := h.props[prop]; ok {
+				vdev.Properties[prop] = fields[i+1]
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (h *vdevHandler) add(vdev *Vdev) {
+	h.vdevs = append(h.vdevs, vdev)
+	if _, ok := h.index[vdev.Name]; !ok {
+		h.index[vdev.Name] = vdev
+	}
+}
+
+func (h *vdevHandler) wantList() bool {
+	for _, prop := range vdevListProps {
+		if _, ok := h.props[prop]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *vdevHandler) result() []Vdev {
+	result := make([]Vdev, len(h.vdevs))
+	for i, vdev := range h.vdevs {
+		result[i] = *vdev
+	}
+	return result
+}
+
+// vdevType derives the vdev type from the name reported by zpool, ie - `mirror-0`, `raidz2-1`.
+func vdevType(name string, depth int) VdevType {
+	if depth == 0 {
+		return VdevRoot
+	}
+	i := strings.LastIndexByte(name, '-')
+	if i <= 0 || strings.HasPrefix(name, `/`) {
+		return VdevDisk
+	}
+	prefix := name[:i]
+	switch {
+	case prefix == string(VdevMirror):
+		return VdevMirror
+	case prefix == string(VdevRaidz), strings.HasPrefix(prefix, string(VdevRaidz)) && len(prefix) == len(VdevRaidz)+1:
+		return VdevRaidz
+	case strings.HasPrefix(prefix, string(VdevDraid)) && strings.Contains(prefix, `:`):
+		return VdevDraid
+	case prefix == string(VdevReplacing):
+		return VdevReplacing
+	case prefix == string(VdevSpare):
+		return VdevSpare
+	case prefix == string(VdevIndirect):
+		return VdevIndirect
+	}
+
+	return VdevDisk
+}
+
+func newVdevHandler(pool string, props []string) *vdevHandler {
+	h := &vdevHandler{
+		pool:  pool,
+		props: make(map[string]struct{}, len(props)),
+		index: make(map[string]*Vdev),
+	}
+	for _, prop := range props {
+		h.props[prop] = struct{}{}
+	}
+	return h
+}
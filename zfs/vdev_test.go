@@ -0,0 +1,95 @@
+package zfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVdevHandler(t *testing.T) {
+	testCases := []struct {
+		name    string
+		props   []string
+		list    bool
+		results []Vdev
+	}{
+		{
+			name:  `status only`,
+			props: []string{`health`, `checksum_errors`},
+			results: []Vdev{
+				{Name: `tank`, Type: VdevRoot, Class: VdevClassNormal, Properties: map[string]string{`health`: `DEGRADED`, `checksum_errors`: `0`}},
+				{Name: `mirror-0`, Parent: `tank`, Type: VdevMirror, Class: VdevClassNormal, Properties: map[string]string{`health`: `DEGRADED`, `checksum_errors`: `0`}},
+				{Name: `sda`, Parent: `mirror-0`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `sdb`, Parent: `mirror-0`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `FAULTED`, `checksum_errors`: `12`}},
+				{Name: `raidz2-1`, Parent: `tank`, Type: VdevRaidz, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `sdc`, Parent: `raidz2-1`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `sdd`, Parent: `raidz2-1`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `sde`, Parent: `raidz2-1`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `sdf`, Parent: `raidz2-1`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `mirror-2`, Parent: `tank`, Type: VdevMirror, Class: VdevClassSpecial, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `nvme0n1`, Parent: `mirror-2`, Type: VdevDisk, Class: VdevClassSpecial, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `nvme1n1`, Parent: `mirror-2`, Type: VdevDisk, Class: VdevClassSpecial, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `nvme2n1`, Parent: `tank`, Type: VdevDisk, Class: VdevClassLog, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `sdg`, Parent: `tank`, Type: VdevDisk, Class: VdevClassCache, Properties: map[string]string{`health`: `ONLINE`, `checksum_errors`: `0`}},
+				{Name: `sdh`, Parent: `tank`, Type: VdevDisk, Class: VdevClassSpare, Properties: map[string]string{`health`: `AVAIL`}},
+			},
+		},
+		{
+			name:  `with allocation`,
+			props: []string{`health`, `allocated`, `size`},
+			list:  true,
+			results: []Vdev{
+				{Name: `tank`, Type: VdevRoot, Class: VdevClassNormal, Properties: map[string]string{`health`: `DEGRADED`, `allocated`: `1500`, `size`: `6000`}},
+				{Name: `mirror-0`, Parent: `tank`, Type: VdevMirror, Class: VdevClassNormal, Properties: map[string]string{`health`: `DEGRADED`, `allocated`: `500`, `size`: `1000`}},
+				{Name: `sda`, Parent: `mirror-0`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `-`, `size`: `1000`}},
+				{Name: `sdb`, Parent: `mirror-0`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `FAULTED`, `allocated`: `-`, `size`: `1000`}},
+				{Name: `raidz2-1`, Parent: `tank`, Type: VdevRaidz, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `900`, `size`: `4000`}},
+				{Name: `sdc`, Parent: `raidz2-1`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `-`, `size`: `1000`}},
+				{Name: `sdd`, Parent: `raidz2-1`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `-`, `size`: `1000`}},
+				{Name: `sde`, Parent: `raidz2-1`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `-`, `size`: `1000`}},
+				{Name: `sdf`, Parent: `raidz2-1`, Type: VdevDisk, Class: VdevClassNormal, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `-`, `size`: `1000`}},
+				{Name: `mirror-2`, Parent: `tank`, Type: VdevMirror, Class: VdevClassSpecial, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `100`, `size`: `1000`}},
+				{Name: `nvme0n1`, Parent: `mirror-2`, Type: VdevDisk, Class: VdevClassSpecial, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `-`, `size`: `1000`}},
+				{Name: `nvme1n1`, Parent: `mirror-2`, Type: VdevDisk, Class: VdevClassSpecial, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `-`, `size`: `1000`}},
+				{Name: `nvme2n1`, Parent: `tank`, Type: VdevDisk, Class: VdevClassLog, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `0`, `size`: `500`}},
+				{Name: `sdg`, Parent: `tank`, Type: VdevDisk, Class: VdevClassCache, Properties: map[string]string{`health`: `ONLINE`, `allocated`: `1000`, `size`: `2000`}},
+				{Name: `sdh`, Parent: `tank`, Type: VdevDisk, Class: VdevClassSpare, Properties: map[string]string{`health`: `AVAIL`, `allocated`: `-`, `size`: `-`}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newVdevHandler(`tank`, tc.props)
+			if h.wantList() != tc.list {
+				t.Fatalf("wantList() = %v, expected %v", h.wantList(), tc.list)
+			}
+			parseFixture(t, `testdata/zpool-status-vdevs.txt`, h.processStatus)
+			if tc.list {
+				parseFixture(t, `testdata/zpool-list-vdevs.txt`, h.processList)
+			}
+			if result := h.result(); !reflect.DeepEqual(result, tc.results) {
+				t.Fatalf("unexpected result:\n%+v\nexpected:\n%+v", result, tc.results)
+			}
+		})
+	}
+}
+
+func TestVdevType(t *testing.T) {
+	testCases := map[string]VdevType{
+		`mirror-0`:           VdevMirror,
+		`raidz-0`:            VdevRaidz,
+		`raidz3-12`:          VdevRaidz,
+		`draid2:4d:1s:8c-0`:  VdevDraid,
+		`replacing-1`:        VdevReplacing,
+		`spare-2`:            VdevSpare,
+		`indirect-0`:         VdevIndirect,
+		`sda`:                VdevDisk,
+		`wwn-0x5000c500a1b2`: VdevDisk,
+		`/var/tmp/file-0`:    VdevDisk,
+	}
+	for name, expected := range testCases {
+		if result := vdevType(name, 1); result != expected {
+			t.Errorf("vdevType(%q) = %q, expected %q", name, result, expected)
+		}
+	}
+}
@@ -3,28 +3,41 @@
 package zfs
 
 import (
-	"encoding/csv"
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"os/exec"
-	"strings"
+	"time"
 )
 
+// DefaultDetectTimeout bounds the detection of the backend, when not configured
+const DefaultDetectTimeout = 10 * time.Second
+
 // ErrInvalidOutput is returned on unparseable CLI output
 var ErrInvalidOutput = errors.New(`invalid output executing command`)
 
 // Client is the primary entrypoint
 type Client interface {
-	PoolNames() ([]string, error)
+	PoolNames(ctx context.Context) ([]string, error)
 	Pool(name string) Pool
 	Datasets(pool string, kind DatasetKind) Datasets
+	ARCStats(ctx context.Context) (map[string]string, error)
+	// SpaceUsage returns the requested space accounting properties (ie - `used`, `quota`) for each user, group or
+	// project within the dataset
+	SpaceUsage(ctx context.Context, dataset string, kind SpaceKind, props ...string) ([]SpaceUsage, error)
+	// Holds returns the user holds on the named snapshots
+	Holds(ctx context.Context, snapshots ...string) ([]Hold, error)
 }
 
 // Pool allows querying pool properties
 type Pool interface {
 	Name() string
-	Properties(props ...string) (PoolProperties, error)
+	// Properties returns the requested pool properties, which may include patterns as accepted by path.Match (ie -
+	// `feature@*`)
+	Properties(ctx context.Context, props ...string) (PoolProperties, error)
+	Vdevs(ctx context.Context, props ...string) ([]Vdev, error)
+	ScanStatus(ctx context.Context) (ScanStatus, error)
+	IOStats(ctx context.Context) (map[string]string, error)
+	Txgs(ctx context.Context) ([]Txg, error)
 }
 
 // PoolProperties provides access to the properties for a pool
@@ -36,7 +49,12 @@ type PoolProperties interface {
 type Datasets interface {
 	Pool() string
 	Kind() DatasetKind
-	Properties(props ...string) ([]DatasetProperties, error)
+	// Root restricts the query to the named dataset and its descendents, up to depth levels below it (unlimited if
+	// negative). Snapshots and bookmarks are one level below the dataset to which they belong.
+	Root(name string, depth int) Datasets
+	// Properties returns the requested properties for each dataset, which may include patterns as accepted by
+	// path.Match (ie - `usedby*`)
+	Properties(ctx context.Context, props ...string) ([]DatasetProperties, error)
 }
 
 // DatasetProperties provides access to the properties for a dataset
@@ -49,63 +67,100 @@ type handler interface {
 	processLine(pool string, line []string) error
 }
 
-type clientImpl struct{}
+// Backend enum of supported CLI output parsers
+type Backend string
+
+const (
+	// BackendAuto selects BackendJSON if the installed ZFS tools support it, otherwise BackendText
+	BackendAuto Backend = `auto`
+	// BackendText parses the tab-separated output of the ZFS tools
+	BackendText Backend = `text`
+	// BackendJSON parses the JSON output of the ZFS tools, available since OpenZFS 2.3
+	BackendJSON Backend = `json`
+)
+
+// Config configures a ZFS Client
+type Config struct {
+	// KstatRoot is the directory from which kstat statistics are read (default: DefaultKstatRoot)
+	KstatRoot string
+	// Backend selects the parser for CLI output (default: BackendAuto)
+	Backend Backend
+	// Runner executes the ZFS tools (default: ExecRunner)
+	Runner Runner
+	// DetectTimeout bounds the detection of the backend for BackendAuto (default: DefaultDetectTimeout)
+	DetectTimeout time.Duration
+}
 
-func (z clientImpl) PoolNames() ([]string, error) {
-	return poolNames()
+type clientImpl struct {
+	kstat  kstatReader
+	runner Runner
+}
+
+func (z clientImpl) PoolNames(ctx context.Context) ([]string, error) {
+	return poolNames(ctx, z.runner)
 }
 
 func (z clientImpl) Pool(name string) Pool {
-	return newPoolImpl(name)
+	return newPoolImpl(name, z.kstat, z.runner)
 }
 
 func (z clientImpl) Datasets(pool string, kind DatasetKind) Datasets {
-	return newDatasetsImpl(pool, kind)
+	return newDatasetsImpl(pool, kind, z.runner)
 }
 
-func execute(pool string, h handler, cmd string, args ...string) error {
-	c := exec.Command(cmd, append(args, pool)...)
-	out, err := c.StdoutPipe()
-	if err != nil {
-		return err
+func (z clientImpl) ARCStats(ctx context.Context) (map[string]string, error) {
+	handler := newKstatHandler()
+	if err := z.kstat(ctx, handler.processNamed, `arcstats`); err != nil {
+		return nil, err
 	}
+	return handler.values, nil
+}
 
-	stderr, err := c.StderrPipe()
-	if err != nil {
-		return err
-	}
+func (z clientImpl) SpaceUsage(ctx context.Context, dataset string, kind SpaceKind, props ...string) ([]SpaceUsage, error) {
+	return spaceUsage(ctx, z.runner, dataset, kind, props...)
+}
 
-	r := csv.NewReader(out)
-	r.Comma = '\t'
-	r.LazyQuotes = true
-	r.ReuseRecord = true
-	r.FieldsPerRecord = 3
+func (z clientImpl) Holds(ctx context.Context, snapshots ...string) ([]Hold, error) {
+	return holds(ctx, z.runner, snapshots...)
+}
 
-	if err = c.Start(); err != nil {
-		return fmt.Errorf("failed to start command '%s': %w", c.String(), err)
+// New instantiates a ZFS Client with the provided Config
+func New(config Config) (Client, error) {
+	if config.KstatRoot == `` {
+		config.KstatRoot = DefaultKstatRoot
 	}
+	if config.Runner == nil {
+		config.Runner = ExecRunner{}
+	}
+	return newClient(clientImpl{
+		kstat:  localKstatReader(config.KstatRoot),
+		runner: config.Runner,
+	}, config.Backend, config.DetectTimeout)
+}
 
-	for {
-		line, err := r.Read()
-		if errors.Is(err, io.EOF) {
-			break
+// newClient wraps the text client in the implementation for the requested backend. Detection of the backend is bound
+// by the timeout, and fails if the ZFS tools could not be queried, rather than selecting a backend.
+func newClient(client clientImpl, backend Backend, timeout time.Duration) (Client, error) {
+	switch backend {
+	case BackendAuto, ``:
+		if timeout <= 0 {
+			timeout = DefaultDetectTimeout
 		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		ok, err := supportsJSON(ctx, client.runner)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if err = h.processLine(pool, line); err != nil {
-			return err
+		if !ok {
+			return client, nil
 		}
+		return newJSONClientImpl(client), nil
+	case BackendJSON:
+		return newJSONClientImpl(client), nil
+	case BackendText:
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unsupported backend '%s'", backend)
 	}
-
-	stde, _ := io.ReadAll(stderr)
-	if err = c.Wait(); err != nil {
-		return fmt.Errorf("failed to execute command '%s'; output: '%s' (%w)", c.String(), strings.TrimSpace(string(stde)), err)
-	}
-	return nil
-}
-
-// New instantiates a ZFS Client
-func New() Client {
-	return clientImpl{}
 }
@@ -0,0 +1,19 @@
+package zfs
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func parseFixture(t *testing.T, path string, parse func(io.Reader) error) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err = parse(f); err != nil {
+		t.Fatal(err)
+	}
+}
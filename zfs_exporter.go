@@ -3,9 +3,11 @@ package main
 import (
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
-	"github.com/pdf/zfs_exporter/v2/collector"
 	"github.com/pdf/zfs_exporter/v2/zfs"
 
 	"github.com/alecthomas/kingpin/v2"
@@ -22,11 +24,31 @@ import (
 
 func main() {
 	var (
+		flags = &flagConfig{}
+
+		configFile              = kingpin.Flag("config.file", "Path to a YAML configuration file. Flags that are set explicitly take precedence over values from the file. Reloaded upon SIGHUP, or a POST to /-/reload when --web.enable-lifecycle is set.").String()
 		metricsPath             = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
+		enableLifecycle         = kingpin.Flag("web.enable-lifecycle", "Enable reloading the configuration via a POST to /-/reload, which is not authenticated.").Default("false").Bool()
 		metricsExporterDisabled = kingpin.Flag(`web.disable-exporter-metrics`, `Exclude metrics about the exporter itself (promhttp_*, process_*, go_*).`).Default(`false`).Bool()
-		deadline                = kingpin.Flag("deadline", "Maximum duration that a collection should run before returning cached data. Should be set to a value shorter than your scrape timeout duration. The current collection run will continue and update the cache when complete (default: 8s)").Default("8s").Duration()
-		pools                   = kingpin.Flag("pool", "Name of the pool(s) to collect, repeat for multiple pools (default: all pools).").Strings()
-		excludes                = kingpin.Flag("exclude", "Exclude datasets/snapshots/volumes that match the provided regex (e.g. '^rpool/docker/'), may be specified multiple times.").Strings()
+		deadline                = kingpin.Flag("deadline", "Maximum duration that a collection should run before returning cached data. Should be set to a value shorter than your scrape timeout duration. The current collection run will continue and update the cache when complete (default: 8s)").Default("8s").IsSetByUser(&flags.deadlineSet).Duration()
+		timeout                 = kingpin.Flag("collector.timeout", "Maximum duration that a collection run may execute ZFS commands, after which they are killed and the collectors are marked as failed, allowing the next collection to start. Should be set to a value longer than the deadline (default: 0, no timeout).").Default("0s").IsSetByUser(&flags.timeoutSet).Duration()
+		pollInterval            = kingpin.Flag("collector.poll-interval", "Refresh collectors in the background at this interval, serving scrapes purely from the cache, rather than collecting upon scrape. The deadline does not apply when polling, 0 disables polling.").Default("0s").IsSetByUser(&flags.pollIntervalSet).Duration()
+		cacheTimestamps         = kingpin.Flag("collector.cache-timestamps", "Expose metrics served from the cache, upon exceeding the deadline, with the timestamp of their collection.").Default("false").Bool()
+		pools                   = kingpin.Flag("pool", "Name of the pool(s) to collect, repeat for multiple pools (default: all pools).").IsSetByUser(&flags.poolsSet).Strings()
+		excludes                = kingpin.Flag("exclude", "Exclude datasets/snapshots/volumes that match the provided regex (e.g. '^rpool/docker/'), may be specified multiple times.").IsSetByUser(&flags.excludesSet).Strings()
+		datasetRoots            = kingpin.Flag("dataset-root", "Name of the dataset(s) below which datasets/snapshots/volumes are collected, rather than entire pools, repeat for multiple datasets (e.g. 'tank/vm').").IsSetByUser(&flags.datasetRootsSet).Strings()
+		userProps               = kingpin.Flag("dataset-user-property", "User property to include as a label on the zfs_dataset_info metric of the dataset collectors, repeat for multiple properties (e.g. 'com.example:owner').").IsSetByUser(&flags.userPropsSet).Strings()
+		kstatRoot               = kingpin.Flag("kstat-root", "Directory from which ZFS kstat statistics are read.").Default(zfs.DefaultKstatRoot).String()
+		backend                 = kingpin.Flag("backend", "Parser for ZFS CLI output, one of: auto, text, json. The json backend requires OpenZFS 2.3 or later, auto selects it when supported by the installed ZFS tools.").Default(string(zfs.BackendAuto)).Enum(string(zfs.BackendAuto), string(zfs.BackendText), string(zfs.BackendJSON))
+		execPrefix              = kingpin.Flag("exec.prefix", "Command prefix for executing the ZFS tools, separated by spaces (e.g. 'sudo -n').").String()
+		execZFSPath             = kingpin.Flag("exec.zfs-path", "Path to the zfs executable.").Default("zfs").String()
+		execZpoolPath           = kingpin.Flag("exec.zpool-path", "Path to the zpool executable.").Default("zpool").String()
+		execEnv                 = kingpin.Flag("exec.env", "Additional environment variable for executing the ZFS tools, in the KEY=VALUE format, may be specified multiple times.").Strings()
+		probeTargets            = kingpin.Flag("probe.target", "Host that may be probed via /probe, repeat for multiple hosts (e.g. 'nas.example.com:2222'). Probing is disabled unless at least one target is configured.").Strings()
+		probeSSHCommand         = kingpin.Flag("probe.ssh.command", "The ssh client used to execute the ZFS tools on /probe targets.").Default(zfs.DefaultSSHCommand).String()
+		probeSSHUser            = kingpin.Flag("probe.ssh.user", "Login user for /probe targets (default: ssh client default).").String()
+		probeSSHIdentityFile    = kingpin.Flag("probe.ssh.identity-file", "Private key used to authenticate to /probe targets (default: ssh client default).").String()
+		probeSSHOptions         = kingpin.Flag("probe.ssh.option", "Additional ssh client option for /probe targets, in the ssh_config format (e.g. 'StrictHostKeyChecking=yes'), may be specified multiple times.").Strings()
 		toolkitFlags            = kingpinflag.AddFlags(kingpin.CommandLine, ":9134")
 	)
 
@@ -40,16 +62,21 @@ func main() {
 	logger.Info("Starting zfs_exporter", "version", version.Info())
 	logger.Info("Build context", "context", version.BuildContext())
 
-	c, err := collector.NewZFS(collector.ZFSConfig{
-		DisableMetrics: *metricsExporterDisabled,
-		Deadline:       *deadline,
-		Pools:          *pools,
-		Excludes:       *excludes,
-		Logger:         logger,
-		ZFSClient:      zfs.New(),
+	zfsClient, err := zfs.New(zfs.Config{
+		KstatRoot:     *kstatRoot,
+		Backend:       zfs.Backend(*backend),
+		DetectTimeout: *timeout,
+		Runner: zfs.ExecRunner{
+			Prefix: strings.Fields(*execPrefix),
+			Paths: map[string]string{
+				"zfs":   *execZFSPath,
+				"zpool": *execZpoolPath,
+			},
+			Env: *execEnv,
+		},
 	})
 	if err != nil {
-		logger.Error("Error creating an exporter", "err", err)
+		logger.Error("Error creating ZFS client", "err", err)
 		os.Exit(1)
 	}
 
@@ -58,23 +85,62 @@ func main() {
 		prometheus.DefaultRegisterer = r
 		prometheus.DefaultGatherer = r
 	}
-	prometheus.MustRegister(c)
 	prometheus.MustRegister(versioncollector.NewCollector("zfs_exporter"))
 
-	if len(c.Pools) > 0 {
-		logger.Info("Enabling pools", "pools", strings.Join(c.Pools, ", "))
-	} else {
-		logger.Info("Enabling pools", "pools", "(all)")
+	flags.deadline = deadline
+	flags.timeout = timeout
+	flags.pollInterval = pollInterval
+	flags.pools = pools
+	flags.excludes = excludes
+	flags.datasetRoots = datasetRoots
+	flags.userProps = userProps
+	flags.disableMetrics = *metricsExporterDisabled
+	flags.cacheTimes = *cacheTimestamps
+	permittedTargets := make(map[string]struct{}, len(*probeTargets))
+	for _, target := range *probeTargets {
+		permittedTargets[target] = struct{}{}
+	}
+	loader := &configLoader{
+		path:         *configFile,
+		probeTargets: permittedTargets,
+		flags:        flags,
+		client:       zfsClient,
+		logger:       logger,
+		registerer:   prometheus.DefaultRegisterer,
+		newTargetClient: func(target string, timeout time.Duration) (zfs.Client, error) {
+			return zfs.NewSSH(target, zfs.Config{
+				KstatRoot:     *kstatRoot,
+				Backend:       zfs.Backend(*backend),
+				DetectTimeout: timeout,
+			}, zfs.SSHConfig{
+				Command:      *probeSSHCommand,
+				User:         *probeSSHUser,
+				IdentityFile: *probeSSHIdentityFile,
+				Options:      *probeSSHOptions,
+			})
+		},
+	}
+	if err = loader.reload(); err != nil {
+		logger.Error("Error creating an exporter", "err", err)
+		os.Exit(1)
 	}
 
-	collectorNames := make([]string, 0, len(c.Collectors))
-	for n, c := range c.Collectors {
-		if *c.Enabled {
-			collectorNames = append(collectorNames, n)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := loader.reload(); err != nil {
+				logger.Error("Error reloading configuration", "err", err)
+				continue
+			}
+			logger.Info("Reloaded configuration")
 		}
-	}
-	logger.Info("Enabling collectors", "collectors", strings.Join(collectorNames, ", "))
+	}()
 
+	if *enableLifecycle {
+		http.Handle("/-/reload", loader)
+	}
+	http.Handle("/probe", probeHandler{loader: loader})
 	http.Handle(*metricsPath, promhttp.Handler())
 	if *metricsPath != "/" {
 		landingConfig := web.LandingConfig{